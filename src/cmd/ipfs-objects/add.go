@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ipobj"
+	ipnet "ipobj-net"
+	tree "ipobj-tree"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+func add(cfg Config, args []string) error {
+	var f flag.FlagSet
+	var keyfile string
+	var storeDir string
+	var interval time.Duration
+	var offline bool
+	f.StringVar(&keyfile, "k", "", "Secret key file")
+	f.StringVar(&storeDir, "store", defaultStoreDir(), "Directory keeping the blocks")
+	f.DurationVar(&interval, "t", time.Hour, "Time interval between advertisements")
+	f.BoolVar(&offline, "n", false, "Only store the blocks, do not provide them")
+	f.Parse(args[1:])
+
+	path := f.Arg(0)
+	if path == "" {
+		return fmt.Errorf("Please specify a file or directory to add")
+	} else if storeDir == "" {
+		return fmt.Errorf("Please specify a block directory with -store")
+	}
+
+	store, err := tree.NewDirStore(storeDir)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	var root ipobj.ObjAddr
+	if info.IsDir() {
+		root, err = tree.BuildDir(store, path)
+	} else {
+		var file *os.File
+		file, err = os.Open(path)
+		if err != nil {
+			return err
+		}
+		root, _, err = tree.BuildFile(store, file)
+		file.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Stored %s in %s\n", root, storeDir)
+	fmt.Println(root)
+
+	if offline {
+		return nil
+	}
+
+	var sk ic.PrivKey
+	if keyfile == "" {
+		sk, err = dummySecretKey()
+	} else {
+		sk, err = readKeyFile(keyfile)
+	}
+	if err != nil {
+		return err
+	}
+
+	var config ipnet.NetworkConfig
+	config.ListenAddresses, err = cfg.ListenAddrs.Get()
+	if err != nil {
+		return err
+	}
+	net, err := ipnet.NewNetwork(context.Background(), config, tree.NewPeer(store), sk)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Peer id: %s\n", ipobj.PeerIdString(net.Id()))
+
+	ctx := contextWithSignal(context.Background())
+
+	for {
+		deadline := time.Now().Add(interval)
+
+		// Provide every block the peer serves, including earlier additions
+		addrs, err := store.Addrs()
+		if err != nil {
+			return err
+		}
+		for _, addr := range addrs {
+			err = net.ProvideObject(ctx, addr, true)
+			if err == context.Canceled {
+				return nil
+			} else if err != nil {
+				fmt.Fprintf(os.Stderr, "Advertise block %s: %s\n", addr, err)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Advertised %d blocks of %s\n", len(addrs), storeDir)
+
+		// Sleep until next deadline
+		ctx2, cancel := context.WithDeadline(ctx, deadline)
+		<-ctx2.Done()
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func defaultStoreDir() string {
+	home := os.Getenv("HOME")
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".ipfs-objects", "blocks")
+}
@@ -4,63 +4,147 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"sync"
 	"time"
 
 	"ipobj"
 	ipnet "ipobj-net"
 	osr "ipobj-osr"
 
-	base58 "github.com/jbenet/go-base58"
 	ic "github.com/libp2p/go-libp2p-crypto"
 )
 
+// Number of malformed records after which the records of a peer are ignored
+const maxOffenses = 10
+
 type advertisePeer struct {
 	ipobj.NullPeerType
+	lock   sync.Mutex
 	values map[string][]byte
+	// Encoded records, current and past, served as objects for history
+	blocks map[string][]byte
+	// Number of malformed records sent by each peer
+	offenses map[string]int
+}
+
+// Set the current record for key, must be called with the lock held
+func (ap *advertisePeer) setValue(key string, value []byte) {
+	ap.values[key] = value
+	ap.addBlock(value)
+}
+
+// Serve an encoded record as object, without its witnesses to match its
+// address. Must be called with the lock held.
+func (ap *advertisePeer) addBlock(value []byte) {
+	block, err := osr.StripWitnesses(value)
+	if err != nil {
+		fmt.Printf("record address error: %s\n", err)
+		return
+	}
+	addr, err := osr.RecordObjAddr(block)
+	if err != nil {
+		fmt.Printf("record address error: %s\n", err)
+		return
+	}
+	ap.blocks[string(addr)] = block
+}
+
+func (ap *advertisePeer) blockAddrs() []ipobj.ObjAddr {
+	ap.lock.Lock()
+	defer ap.lock.Unlock()
+	var addrs []ipobj.ObjAddr
+	for addr := range ap.blocks {
+		addrs = append(addrs, ipobj.ObjAddr(addr))
+	}
+	return addrs
+}
+
+func (ap *advertisePeer) GetObject(obj ipobj.ObjAddr) (io.Reader, error) {
+	ap.lock.Lock()
+	defer ap.lock.Unlock()
+	block, ok := ap.blocks[string(obj)]
+	if !ok {
+		return nil, ipobj.NoObject
+	}
+	return ipobj.BytesToReader(block), nil
 }
 
 func (ap *advertisePeer) GetRecord(key string) ([]byte, error) {
 	fmt.Printf("GetRecord %s\n", key)
-	return ap.values[key], nil
+	ap.lock.Lock()
+	value := ap.values[key]
+	ap.lock.Unlock()
+	if _, _, err := decodeRecord(value, key); err == ipobj.ErrExpired {
+		fmt.Printf("%s: record expired, stop serving it\n", key)
+		return nil, nil
+	}
+	return value, nil
 }
 
 func (ap *advertisePeer) NewRecord(key string, value []byte, peer []byte) {
-	newRec, err := osr.Decode(value)
+	t, err := ipobj.RecordTypeFor(key)
 	if err != nil {
-		fmt.Printf("%s: new record from %s: decode error %s", key, base58.Encode(peer), err)
+		fmt.Printf("%s: new record from %s: %s\n", key, ipobj.PeerIdString(peer), err)
 		return
 	}
 
-	newKey, err := newRec.Path()
-	if err != nil {
-		fmt.Printf("%s: new record from %s: path error %s", key, base58.Encode(peer), err)
+	ap.lock.Lock()
+	offenses := ap.offenses[string(peer)]
+	ap.lock.Unlock()
+	if offenses >= maxOffenses {
 		return
 	}
-	if "/iprs"+newKey != key {
-		fmt.Printf("%s: new record from %s: mismatching path /iprs%s", key, base58.Encode(peer), newKey)
+
+	newRec, err := t.Decode(key, value)
+	if osr.IsRejected(err) {
+		ap.lock.Lock()
+		ap.offenses[string(peer)]++
+		offenses = ap.offenses[string(peer)]
+		ap.lock.Unlock()
+		fmt.Printf("%s: rejected record from %s (%d offenses): %s\n", key, ipobj.PeerIdString(peer), offenses, err)
+		if offenses == maxOffenses {
+			fmt.Printf("ignoring further records from %s\n", ipobj.PeerIdString(peer))
+		}
+		return
+	} else if err != nil {
+		fmt.Printf("%s: new record from %s: decode error %s\n", key, ipobj.PeerIdString(peer), err)
 		return
 	}
 
+	ap.lock.Lock()
+	defer ap.lock.Unlock()
+
 	recData, hasRec := ap.values[key]
 	if !hasRec {
-		fmt.Printf("%s: new record from %s\n", key, base58.Encode(peer))
+		fmt.Printf("%s: new record from %s\n", key, ipobj.PeerIdString(peer))
 		return
 	}
 
-	rec, err := osr.Decode(recData)
-	if err != nil {
-		fmt.Printf("%s: decode OSR error: %s", key, err)
+	rec, err := t.Decode(key, recData)
+	if err == ipobj.ErrExpired {
+		fmt.Printf("%s: replace expired record with record from %s%s\n", key, ipobj.PeerIdString(peer), recordOrder(t, newRec))
+		ap.setValue(key, value)
+		return
+	} else if err != nil {
+		fmt.Printf("%s: decode record error: %s\n", key, err)
 		return
 	}
 
-	if newRec.Order == rec.Order {
-		fmt.Printf("%s: same record from %s\n", key, base58.Encode(peer))
-	} else if newRec.Order < rec.Order {
-		fmt.Printf("%s: old record from %s (%d)\n", key, base58.Encode(peer), newRec.Order)
+	cmp, err := t.Compare(newRec, rec)
+	if err != nil {
+		fmt.Printf("%s: new record from %s: compare error %s\n", key, ipobj.PeerIdString(peer), err)
+	} else if cmp == 0 {
+		fmt.Printf("%s: same record from %s\n", key, ipobj.PeerIdString(peer))
+	} else if cmp < 0 {
+		fmt.Printf("%s: old record from %s%s\n", key, ipobj.PeerIdString(peer), recordOrder(t, newRec))
+	} else if t.IsRevoked(newRec) {
+		fmt.Printf("%s: record revoked by %s%s\n", key, ipobj.PeerIdString(peer), recordOrder(t, newRec))
+		ap.setValue(key, value)
 	} else {
-		fmt.Printf("%s: newer record from %s (%d)\n", key, base58.Encode(peer), newRec.Order)
-		ap.values[key] = value
+		fmt.Printf("%s: newer record from %s%s\n", key, ipobj.PeerIdString(peer), recordOrder(t, newRec))
+		ap.setValue(key, value)
 	}
 }
 
@@ -68,8 +152,10 @@ func advertise(cfg Config, args []string) error {
 	var f flag.FlagSet
 	var keyfile string
 	var interval time.Duration
+	var previous stringList
 	f.StringVar(&keyfile, "k", "", "Secret key file")
 	f.DurationVar(&interval, "t", time.Hour, "Time interval between advertisements")
+	f.Var(&previous, "prev", "Previous version of the record to serve (repeatable)")
 	f.Parse(args[1:])
 
 	var err error
@@ -88,21 +174,22 @@ func advertise(cfg Config, args []string) error {
 		return err
 	}
 
-	if recordKey == "" {
-		rec, err := osr.Decode(recordData)
-		if err != nil {
-			return err
-		}
-		recordKey, err = rec.Path()
-		if err != nil {
-			return err
-		}
-		recordKey = "/iprs" + recordKey
+	_, recordKey, err = decodeRecord(recordData, recordKey)
+	if err != nil && err != ipobj.ErrExpired {
+		return err
 	}
 
 	var peer *advertisePeer = new(advertisePeer)
-	peer.values = map[string][]byte{
-		recordKey: recordData,
+	peer.values = map[string][]byte{}
+	peer.blocks = map[string][]byte{}
+	peer.offenses = map[string]int{}
+	peer.setValue(recordKey, recordData)
+	for _, file := range previous {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		peer.addBlock(data)
 	}
 
 	var config ipnet.NetworkConfig
@@ -115,7 +202,7 @@ func advertise(cfg Config, args []string) error {
 		return err
 	}
 
-	fmt.Printf("Peer id: %s\n", base58.Encode(net.Id()))
+	fmt.Printf("Peer id: %s\n", ipobj.PeerIdString(net.Id()))
 	// list out our addresses
 	addrs, err := net.InterfaceListenAddresses()
 	if err != nil {
@@ -134,12 +221,19 @@ func advertise(cfg Config, args []string) error {
 		deadline := time.Now().Add(interval)
 
 		cid := ipobj.NewRecordObjAddr(recordKey)
-		fmt.Printf("Advertise CID: %s\n", base58.Encode(cid))
+		fmt.Printf("Advertise CID: %s\n", cid)
 		net.ProvideObject(ctx, cid, true)
 		if err != nil {
 			return err
 		}
 
+		for _, addr := range peer.blockAddrs() {
+			err = net.ProvideObject(ctx, addr, true)
+			if err != nil {
+				fmt.Printf("Advertise record version %s: %s\n", addr, err)
+			}
+		}
+
 		// Sleep until next deadline
 		ctx2, _ := context.WithDeadline(ctx, deadline)
 		<-ctx2.Done()
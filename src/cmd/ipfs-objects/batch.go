@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	osr "ipobj-osr"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+func genbatch(args []string) error {
+	var f flag.FlagSet
+	var keyfile string
+	var order uint64
+	var output string
+	var input string
+	var format string
+	var eol time.Duration
+	var raw bool
+	var clockfile string
+	f.StringVar(&keyfile, "k", "", "Secret key file")
+	f.StringVar(&output, "o", "", "Output directory, records are written to <salt>.osr")
+	f.StringVar(&input, "i", "", "File listing a salt and a payload per line")
+	f.Uint64Var(&order, "n", 0, "Record order (default: next order of the local clock)")
+	f.StringVar(&format, "f", "json", "Record format (json, cbor, protobuf)")
+	f.DurationVar(&eol, "eol", 0, "Record lifetime (0 for no expiry)")
+	f.BoolVar(&raw, "raw", false, "Accept any string as payload instead of a CID or path")
+	f.StringVar(&clockfile, "clock", defaultClockFile(), "File keeping the last order of each record (empty to disable)")
+	f.Parse(args[1:])
+
+	if output == "" {
+		return fmt.Errorf("Please specify an output directory with -o")
+	}
+
+	codec, err := osr.CodecByPath("/" + format)
+	if err != nil {
+		return fmt.Errorf("Unknown record format %s", format)
+	}
+
+	var sk ic.PrivKey
+	if keyfile == "" {
+		sk, err = dummySecretKey()
+	} else {
+		sk, err = readKeyFile(keyfile)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Entries are given as salt=payload arguments or in the input file
+	entries := f.Args()
+	if input != "" {
+		lines, err := readBatchInput(input)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, lines...)
+	}
+
+	clock := osr.NewClock()
+	if clockfile != "" {
+		clock, err = osr.OpenClock(clockfile)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	var recs []*osr.Record
+	var keys []string
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("Invalid entry %#v, expected salt=payload", entry)
+		} else if parts[0] == "" {
+			// Records are written to <salt>.osr and would overwrite each other
+			return fmt.Errorf("Invalid entry %#v, the salt must not be empty", entry)
+		}
+
+		rec := &osr.Record{
+			Salt: parts[0],
+			CID:  parts[1],
+		}
+		err = osr.CheckSalt(rec.Salt)
+		if err != nil {
+			return fmt.Errorf("%s: %#v", err, rec.Salt)
+		}
+		if !raw {
+			_, err = rec.GetPayload()
+			if err != nil {
+				return fmt.Errorf("%s: %#v", err, rec.CID)
+			}
+		}
+		if eol != 0 {
+			rec.EOL = uint64(now.Add(eol).Unix())
+		}
+
+		path, err := osr.Path(rec.Salt, sk.GetPublic())
+		if err != nil {
+			return err
+		}
+		key := osr.Key(path)
+
+		rec.Order = order
+		if order == 0 {
+			rec.Order, err = clock.Next(key, now)
+			if err != nil {
+				return err
+			}
+		}
+
+		recs = append(recs, rec)
+		keys = append(keys, key)
+	}
+
+	data, err := osr.EncodeBatch(recs, sk, codec)
+	if err != nil {
+		return err
+	}
+
+	for i, rec := range recs {
+		file := filepath.Join(output, filepath.FromSlash(rec.Salt)+".osr")
+		err = os.MkdirAll(filepath.Dir(file), 0755)
+		if err != nil {
+			return err
+		}
+		err = writeOutput(file, data[i])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Generated record: %s\n", keys[i])
+	}
+
+	return nil
+}
+
+// Read batch entries from a file with a salt and a payload separated by
+// spaces on each line. Empty lines and lines starting with # are ignored.
+func readBatchInput(file string) ([]string, error) {
+	in, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		} else if len(fields) != 2 {
+			return nil, fmt.Errorf("%s: invalid line %#v", file, scanner.Text())
+		}
+		entries = append(entries, fields[0]+"="+fields[1])
+	}
+	return entries, scanner.Err()
+}
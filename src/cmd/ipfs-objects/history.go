@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"ipobj"
+	ipnet "ipobj-net"
+	osr "ipobj-osr"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+func history(cfg Config, args []string) error {
+	var f flag.FlagSet
+	var keyfile string
+	var timeout time.Duration
+	var key string
+	f.StringVar(&keyfile, "k", "", "Secret key file")
+	f.DurationVar(&timeout, "t", 0, "Timeout")
+	f.StringVar(&key, "key", "", "Record key or CID, required for compact records")
+	f.Parse(args[1:])
+
+	var err error
+	key, err = parseRecordKey(key)
+	if err != nil {
+		return err
+	}
+
+	var sk ic.PrivKey
+	if keyfile == "" {
+		sk, err = dummySecretKey()
+	} else {
+		sk, err = readKeyFile(keyfile)
+	}
+	if err != nil {
+		return err
+	}
+
+	var pk ic.PubKey
+	if key != "" {
+		pk, err = osr.PublicKeyFromPath(key)
+		if err == osr.ErrNotInline {
+			pk = nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	config := ipnet.NetworkConfig{
+		ClientOnly: true,
+	}
+	config.ListenAddresses, err = cfg.ListenAddrs.Get()
+	if err != nil {
+		return err
+	}
+
+	net, err := ipnet.NewNetwork(context.Background(), config, ipobj.NullPeer, sk)
+	if err != nil {
+		return err
+	}
+
+	ctx := contextWithSignal(context.Background())
+	if timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for _, recordFile := range f.Args() {
+		recordData, err := ioutil.ReadFile(recordFile)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s:\n", recordFile)
+		err = osr.History(ctx, net, recordData, pk, func(rec *osr.Record, addr ipobj.ObjAddr) error {
+			content := rec.CID
+			if rec.Revoked {
+				content = "(revoked)"
+			}
+			fmt.Printf("  %d\t%s\t%s\n", rec.Order, addr, content)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %s", recordFile, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"ipobj"
+	ipnet "ipobj-net"
+	osr "ipobj-osr"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+func inspect(cfg Config, args []string) error {
+	var f flag.FlagSet
+	var keyfile string
+	var timeout time.Duration
+	var key string
+	var with string
+	f.StringVar(&keyfile, "k", "", "Secret key file")
+	f.DurationVar(&timeout, "t", 30*time.Second, "Time to look for records on the network")
+	f.StringVar(&key, "key", "", "Record key or CID, required for compact records")
+	f.StringVar(&with, "with", "", "Second record to compare with")
+	f.Parse(args[1:])
+
+	key, err := parseRecordKey(key)
+	if err != nil {
+		return err
+	}
+
+	source := f.Arg(0)
+	if source == "" {
+		return fmt.Errorf("Please specify a record file, - for stdin, or a /%s path", osr.Namespace)
+	}
+
+	var net *ipnet.Network
+	load := func(source string) (*osr.Record, error) {
+		if !strings.HasPrefix(source, "/"+osr.Namespace+"/") {
+			return readRecord(source, key)
+		}
+
+		if net == nil {
+			var err error
+			net, err = clientNetwork(cfg, keyfile)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(contextWithSignal(context.Background()), timeout)
+		defer cancel()
+		return fetchRecord(ctx, net, source)
+	}
+
+	rec, err := load(source)
+	if err != nil {
+		return fmt.Errorf("%s: %s", source, err)
+	}
+	printRecord(source, osr.RecordType, rec)
+
+	if with == "" {
+		return nil
+	}
+
+	other, err := load(with)
+	if err != nil {
+		return fmt.Errorf("%s: %s", with, err)
+	}
+	printRecord(with, osr.RecordType, other)
+
+	cmp, err := osr.Compare(rec, other)
+	if err != nil {
+		return err
+	} else if cmp > 0 {
+		fmt.Printf("%s wins over %s\n", source, with)
+	} else if cmp < 0 {
+		fmt.Printf("%s wins over %s\n", with, source)
+	} else {
+		fmt.Printf("%s and %s are the same record\n", source, with)
+	}
+
+	return nil
+}
+
+// Read and verify a record from a file, or stdin if file is "-". key is the
+// record key of compact records and may be empty.
+func readRecord(file string, key string) (*osr.Record, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if key == "" {
+		return osr.Decode(data)
+	}
+	return osr.DecodeForPath(data, key)
+}
+
+// Start a client only network node, with the key in keyfile or a dummy key
+func clientNetwork(cfg Config, keyfile string) (*ipnet.Network, error) {
+	var err error
+	var sk ic.PrivKey
+	if keyfile == "" {
+		sk, err = dummySecretKey()
+	} else {
+		sk, err = readKeyFile(keyfile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := ipnet.NetworkConfig{
+		ClientOnly: true,
+	}
+	config.ListenAddresses, err = cfg.ListenAddrs.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	return ipnet.NewNetwork(context.Background(), config, ipobj.NullPeer, sk)
+}
+
+// Get the most recent valid record for key until ctx is done
+func fetchRecord(ctx context.Context, net ipobj.Network, key string) (*osr.Record, error) {
+	var best *osr.Record
+	for value := range net.GetRecord(ctx, key) {
+		rec, err := osr.DecodeStrict(value.Content, key)
+		if err != nil {
+			continue
+		}
+
+		if best != nil {
+			cmp, err := osr.Compare(rec, best)
+			if err != nil || cmp <= 0 {
+				continue
+			}
+		}
+		best = rec
+	}
+
+	if best == nil {
+		return nil, osr.ErrNoValidRecord
+	}
+	return best, nil
+}
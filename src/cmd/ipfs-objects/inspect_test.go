@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	osr "ipobj-osr"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+const testCID = "/ipfs/QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn"
+
+// Run inspect with args and return what it printed
+func testInspect(t *testing.T, args ...string) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	err = inspect(Config{}, append([]string{"inspect"}, args...))
+	w.Close()
+	out, rerr := ioutil.ReadAll(r)
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	return string(out), err
+}
+
+func TestInspect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inspect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sk, _, err := ic.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compactPath, err := osr.CompactPath("web", sk.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(name string, rec *osr.Record, compact bool) string {
+		var data []byte
+		var err error
+		if compact {
+			data, err = rec.EncodeCompact(sk, osr.CBORCodec)
+		} else {
+			data, err = rec.Encode(sk, osr.CBORCodec)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		file := filepath.Join(dir, name)
+		err = ioutil.WriteFile(file, data, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	v1 := write("v1.osr", &osr.Record{CID: testCID, Order: 1, Salt: "web"}, false)
+	v2 := write("v2.osr", &osr.Record{CID: testCID, Order: 2, Salt: "web"}, false)
+	compact := write("compact.osr", &osr.Record{CID: testCID, Order: 3, Salt: "web"}, true)
+	tampered := filepath.Join(dir, "tampered.osr")
+	data, err := ioutil.ReadFile(v1)
+	if err == nil {
+		data[len(data)/2] ^= 1
+		err = ioutil.WriteFile(tampered, data, 0644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		args   []string
+		output []string
+		err    bool
+	}{
+		{"record", []string{v1}, []string{v1 + ": valid record", "  salt:    web", "  order:   1", "  cid:     " + testCID}, false},
+		{"newer", []string{"-with", v2, v1}, []string{v2 + " wins over " + v1}, false},
+		{"older", []string{"-with", v1, v2}, []string{v2 + " wins over " + v1}, false},
+		{"same", []string{"-with", v1, v1}, []string{v1 + " and " + v1 + " are the same record"}, false},
+		{"compact", []string{"-key", osr.Key(compactPath), compact}, []string{"  path:    " + osr.Key(compactPath)}, false},
+		{"compact without key", []string{compact}, nil, true},
+		{"tampered", []string{tampered}, nil, true},
+		{"missing file", []string{filepath.Join(dir, "missing.osr")}, nil, true},
+		{"missing second record", []string{"-with", filepath.Join(dir, "missing.osr"), v1}, nil, true},
+		{"no record", nil, nil, true},
+	}
+
+	for _, c := range cases {
+		out, err := testInspect(t, c.args...)
+		if (err != nil) != c.err {
+			t.Errorf("%s: got error %v", c.name, err)
+			continue
+		}
+		for _, line := range c.output {
+			if !strings.Contains(out, line+"\n") {
+				t.Errorf("%s: missing %q in output:\n%s", c.name, line, out)
+			}
+		}
+	}
+}
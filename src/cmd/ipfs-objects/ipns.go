@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	osr "ipobj-osr"
+
+	base58 "github.com/jbenet/go-base58"
+)
+
+func ipns(args []string) error {
+	var f flag.FlagSet
+	var keyfile string
+	var output string
+	var format string
+	var fromIPNS bool
+	f.StringVar(&keyfile, "k", "", "Secret key file of the record")
+	f.StringVar(&output, "o", "", "Output file")
+	f.StringVar(&format, "f", "json", "Record format when converting from IPNS (json, cbor, protobuf)")
+	f.BoolVar(&fromIPNS, "import", false, "Convert an IPNS entry to an OSR instead")
+	f.Parse(args[1:])
+
+	if keyfile == "" {
+		return fmt.Errorf("Please specify a key file with -k")
+	}
+
+	sk, err := readKeyFile(keyfile)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(f.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if fromIPNS {
+		codec, err := osr.CodecByPath("/" + format)
+		if err != nil {
+			return fmt.Errorf("Unknown record format %s", format)
+		}
+
+		rec, err := osr.FromIPNS(data, sk.GetPublic())
+		if err != nil {
+			return err
+		}
+
+		path, err := rec.Path()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Generated record: %s\n", osr.Key(path))
+
+		data, err = rec.Encode(sk, codec)
+		if err != nil {
+			return err
+		}
+		return writeOutput(output, data)
+	}
+
+	rec, err := osr.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	data, err = rec.ToIPNS(sk)
+	if err != nil {
+		return err
+	}
+
+	hash, err := sk.GetPublic().Hash()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Generated IPNS entry: /ipns/%s\n", base58.Encode(hash))
+
+	return writeOutput(output, data)
+}
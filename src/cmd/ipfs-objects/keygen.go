@@ -6,16 +6,20 @@ import (
 	"fmt"
 	"io/ioutil"
 
+	osr "ipobj-osr"
+
 	ic "github.com/libp2p/go-libp2p-crypto"
 )
 
 func keygen(args []string) error {
 	var f flag.FlagSet
 	var out string
+	var pubout string
 	var keytype string
 	var keysize int
 	f.StringVar(&out, "o", "", "Output file")
-	f.StringVar(&keytype, "t", "ed25519", "Key Type")
+	f.StringVar(&pubout, "p", "", "Public key output file")
+	f.StringVar(&keytype, "t", "ed25519", "Key Type (ed25519, rsa, secp256k1, ecdsa, read)")
 	f.IntVar(&keysize, "s", 4096, "Key Size (for RSA)")
 	f.Parse(args[1:])
 
@@ -26,13 +30,22 @@ func keygen(args []string) error {
 	var sk ic.PrivKey
 	var err error
 
+	if keytype == "read" {
+		return genreadkey(out)
+	}
+
 	switch keytype {
 	case "ed25519":
 		sk, _, err = ic.GenerateEd25519Key(rand.Reader)
 	case "rsa":
 		sk, _, err = ic.GenerateKeyPairWithReader(ic.RSA, keysize, rand.Reader)
+	case "secp256k1":
+		sk, _, err = ic.GenerateKeyPairWithReader(ic.Secp256k1, 256, rand.Reader)
+	case "ecdsa":
+		// ECDSA keys use the P-256 curve
+		sk, _, err = ic.GenerateKeyPairWithReader(ic.ECDSA, 256, rand.Reader)
 	default:
-		err = fmt.Errorf("Supported key types: rsa, ed25519")
+		err = fmt.Errorf("Supported key types: rsa, ed25519, secp256k1, ecdsa, read")
 	}
 
 	if err != nil {
@@ -44,5 +57,28 @@ func keygen(args []string) error {
 		return err
 	}
 
+	if pubout != "" {
+		pub, err := sk.GetPublic().Bytes()
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(pubout, pub, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
 	return ioutil.WriteFile(out, bytes, 0600)
 }
+
+// Generate a symmetric read key for sealed records
+func genreadkey(out string) error {
+	key := make([]byte, osr.ReadKeySize)
+	_, err := rand.Read(key)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(out, key, 0600)
+}
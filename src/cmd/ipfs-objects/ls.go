@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"ipobj"
+	ipnet "ipobj-net"
+	osr "ipobj-osr"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+func ls(cfg Config, args []string) error {
+	var f flag.FlagSet
+	var keyfile string
+	var timeout time.Duration
+	f.StringVar(&keyfile, "k", "", "Secret key file")
+	f.DurationVar(&timeout, "t", 30*time.Second, "Time to look for index records")
+	f.Parse(args[1:])
+
+	var err error
+	var sk ic.PrivKey
+	if keyfile == "" {
+		sk, err = dummySecretKey()
+	} else {
+		sk, err = readKeyFile(keyfile)
+	}
+	if err != nil {
+		return err
+	}
+
+	config := ipnet.NetworkConfig{
+		ClientOnly: true,
+	}
+	config.ListenAddresses, err = cfg.ListenAddrs.Get()
+	if err != nil {
+		return err
+	}
+
+	net, err := ipnet.NewNetwork(context.Background(), config, ipobj.NullPeer, sk)
+	if err != nil {
+		return err
+	}
+
+	ctx := contextWithSignal(context.Background())
+
+	for _, path := range f.Args() {
+		keyHash, prefix, err := osr.ParsePath(path)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+
+		ctx2, cancel := context.WithTimeout(ctx, timeout)
+		salts, err := osr.ListSalts(ctx2, net, keyHash, prefix)
+		cancel()
+		if err != nil {
+			fmt.Printf("%s: %s\n", path, err)
+			continue
+		}
+
+		for _, salt := range salts {
+			child, err := osr.PathFromHash(keyHash, salt)
+			if err != nil {
+				fmt.Printf("%s: %s: %s\n", path, salt, err)
+				continue
+			}
+			fmt.Printf("%s\n", osr.Key(child))
+		}
+	}
+
+	return nil
+}
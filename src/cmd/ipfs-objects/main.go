@@ -12,6 +12,7 @@ import (
 
 	"ipobj"
 	ipnet "ipobj-net"
+	osr "ipobj-osr"
 
 	"github.com/ipfs/go-log"
 	ic "github.com/libp2p/go-libp2p-crypto"
@@ -44,20 +45,60 @@ func main() {
 	case "update":
 		err = update(cfg, f.Args())
 		break
+	case "ls":
+		err = ls(cfg, f.Args())
+		break
+	case "history":
+		err = history(cfg, f.Args())
+		break
+	case "add":
+		err = add(cfg, f.Args())
+		break
 	case "gen-osr":
-		err = genosr(f.Args())
+		err = genosr(cfg, f.Args())
+		break
+	case "gen-batch":
+		err = genbatch(f.Args())
+		break
+	case "sign-osr":
+		err = signosr(f.Args())
+		break
+	case "witness":
+		err = witness(f.Args())
+		break
+	case "ipns":
+		err = ipns(f.Args())
+		break
+	case "gen-rotation":
+		err = genrotation(cfg, f.Args())
+		break
+	case "verify-rotation":
+		err = verifyrotation(f.Args())
+		break
+	case "inspect":
+		err = inspect(cfg, f.Args())
 		break
 	default:
 		err = fmt.Errorf("Please specify a valid command: %s invalid", f.Arg(0))
 		fallthrough
 	case "help":
 		fmt.Println("Available commands:")
-		fmt.Println("\thelp      - this help")
-		fmt.Println("\tkeygen    - generate secret key")
-		fmt.Println("\tresolve   - resolve naming record to root block")
-		fmt.Println("\tadvertise - advertise naming record to root block")
-		fmt.Println("\tupdate    - update peers with outdated records")
-		fmt.Println("\tgen-osr   - generate OSR record")
+		fmt.Println("\thelp            - this help")
+		fmt.Println("\tkeygen          - generate secret key")
+		fmt.Println("\tresolve         - resolve naming record to root block")
+		fmt.Println("\tadvertise       - advertise naming record to root block")
+		fmt.Println("\tupdate          - update peers with outdated records")
+		fmt.Println("\tls              - list the salts published under a record path")
+		fmt.Println("\thistory         - list previous versions of a record")
+		fmt.Println("\tadd             - store a file or directory as blocks and provide them")
+		fmt.Println("\tgen-osr         - generate OSR record")
+		fmt.Println("\tgen-batch       - generate OSR records for many salts with one signature")
+		fmt.Println("\tsign-osr        - add a signature to a multi-signature OSR")
+		fmt.Println("\twitness         - attest having seen a record, or list its witnesses")
+		fmt.Println("\tipns            - convert an OSR to an IPNS entry and back")
+		fmt.Println("\tgen-rotation    - generate key rotation record")
+		fmt.Println("\tverify-rotation - verify key rotation record")
+		fmt.Println("\tinspect         - verify and describe a record")
 		break
 	}
 
@@ -80,6 +121,41 @@ func readKeyFile(keyfile string) (ic.PrivKey, error) {
 	return sk, err
 }
 
+// Read a public key file, or the public part of a secret key file
+func readPubKeyFile(keyfile string) (ic.PubKey, error) {
+	bytes, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, err := ic.UnmarshalPublicKey(bytes)
+	if err == nil {
+		return pk, nil
+	}
+
+	sk, err := ic.UnmarshalPrivateKey(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return sk.GetPublic(), nil
+}
+
+// Read a symmetric read key file, as generated by keygen -t read
+func readReadKeyFile(keyfile string) (*[osr.ReadKeySize]byte, error) {
+	bytes, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bytes) != osr.ReadKeySize {
+		return nil, fmt.Errorf("%s: read keys must be %d bytes long", keyfile, osr.ReadKeySize)
+	}
+
+	var key [osr.ReadKeySize]byte
+	copy(key[:], bytes)
+	return &key, nil
+}
+
 func dummySecretKey() (ic.PrivKey, error) {
 	sk, _, err := ic.GenerateEd25519Key(rand.Reader)
 	return sk, err
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	osr "ipobj-osr"
@@ -11,19 +15,142 @@ import (
 	ic "github.com/libp2p/go-libp2p-crypto"
 )
 
-func genosr(args []string) error {
+func genosr(cfg Config, args []string) error {
 	var f flag.FlagSet
 	var keyfile string
 	var order uint64
 	var output string
 	var salt string
+	var format string
+	var notBefore time.Duration
+	var eol time.Duration
+	var rotation string
+	var signers stringList
+	var threshold uint
+	var revoke bool
+	var prev string
+	var raw bool
+	var list stringList
+	var sealKey string
+	var sealTo stringList
+	var compact bool
+	var clockfile string
+	var seed bool
+	var adopt bool
 	f.StringVar(&keyfile, "k", "", "Secret key file")
 	f.StringVar(&output, "o", "", "Output file")
 	f.StringVar(&salt, "s", "", "Salt")
-	f.Uint64Var(&order, "n", uint64(time.Now().Unix()), "Record order")
+	f.Uint64Var(&order, "n", 0, "Record order (default: next order of the local clock)")
+	f.StringVar(&format, "f", "json", "Record format (json, cbor, protobuf)")
+	f.DurationVar(&notBefore, "nbf", 0, "Delay before the record becomes valid")
+	f.DurationVar(&eol, "eol", 0, "Record lifetime (0 for no expiry)")
+	f.StringVar(&rotation, "r", "", "Rotation record designating the key as successor")
+	f.Var(&signers, "m", "Public key file of a signer, for multi-signature records (repeatable)")
+	f.UintVar(&threshold, "t", 0, "Number of signatures required for multi-signature records (default: all)")
+	f.BoolVar(&revoke, "revoke", false, "Generate a tombstone revoking the record")
+	f.StringVar(&prev, "prev", "", "Previous version of the record, to link the history")
+	f.BoolVar(&raw, "raw", false, "Accept any string as payload instead of a CID or path")
+	f.Var(&list, "list", "Generate the index record for the salt prefix -s, listing this salt (repeatable)")
+	f.StringVar(&sealKey, "seal-key", "", "Read key file to seal the payload with")
+	f.Var(&sealTo, "seal-to", "Public key file of a recipient to seal the payload to (repeatable)")
+	f.BoolVar(&compact, "compact", false, "Inline the public key in the record path instead of the record (ed25519 and secp256k1 keys)")
+	f.StringVar(&clockfile, "clock", defaultClockFile(), "File keeping the last order of each record (empty to disable)")
+	f.BoolVar(&seed, "seed", false, "Seed the clock from the latest record found on the network")
+	f.BoolVar(&adopt, "adopt", false, "With -seed, follow the order found on the network even if it is too far in the future")
 	f.Parse(args[1:])
 
-	var err error
+	codec, err := osr.CodecByPath("/" + format)
+	if err != nil {
+		return fmt.Errorf("Unknown record format %s", format)
+	}
+
+	// Multi-signature records carry no single key to inline or rotate
+	if len(signers) > 0 && rotation != "" {
+		return fmt.Errorf("-m cannot be combined with -r")
+	} else if len(signers) > 0 && compact {
+		return fmt.Errorf("-m cannot be combined with -compact")
+	}
+
+	var rec osr.Record = osr.Record{
+		CID:   f.Arg(0),
+		Order: order,
+		Salt:  salt,
+	}
+	err = osr.CheckSalt(salt)
+	if err != nil {
+		return fmt.Errorf("%s: %#v", err, salt)
+	}
+
+	if revoke {
+		rec = *osr.NewTombstone(salt, order)
+	} else if len(list) > 0 {
+		index, err := osr.NewIndex(salt, list, order)
+		if err != nil {
+			return err
+		}
+		rec = *index
+		salt = rec.Salt
+	} else if !raw {
+		_, err = rec.GetPayload()
+		if err != nil {
+			return fmt.Errorf("%s: %#v", err, rec.CID)
+		}
+	}
+
+	if sealKey != "" {
+		key, err := readReadKeyFile(sealKey)
+		if err != nil {
+			return err
+		}
+		err = rec.SealWithKey(key)
+		if err != nil {
+			return err
+		}
+	} else if len(sealTo) > 0 {
+		var recipients []ic.PubKey
+		for _, file := range sealTo {
+			pk, err := readPubKeyFile(file)
+			if err != nil {
+				return err
+			}
+			recipients = append(recipients, pk)
+		}
+		err = rec.SealTo(recipients)
+		if err != nil {
+			return err
+		}
+	}
+
+	if prev != "" {
+		prevData, err := ioutil.ReadFile(prev)
+		if err != nil {
+			return err
+		}
+		rec.Prev, err = osr.Hash(prevData)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	if notBefore != 0 {
+		rec.NotBefore = uint64(now.Add(notBefore).Unix())
+	}
+	if eol != 0 {
+		rec.EOL = uint64(now.Add(eol).Unix())
+	}
+
+	orderFor := func(path string) (uint64, error) {
+		if order != 0 {
+			return order, nil
+		}
+		return clockOrder(cfg, clockfile, seed, adopt, osr.Key(path))
+	}
+
+	if len(signers) > 0 {
+		return genmultiosr(rec, signers, uint32(threshold), keyfile, codec, output, orderFor)
+	}
+
 	var sk ic.PrivKey
 	if keyfile == "" {
 		sk, err = dummySecretKey()
@@ -31,24 +158,105 @@ func genosr(args []string) error {
 		sk, err = readKeyFile(keyfile)
 	}
 
-	var rec osr.Record = osr.Record{
-		CID:   f.Arg(0),
-		Order: order,
-		Salt:  salt,
+	var path string
+	if rotation != "" {
+		rec.Rotation, err = ioutil.ReadFile(rotation)
+		if err != nil {
+			return err
+		}
+		rot, err := osr.VerifyRotation(rec.Rotation, sk.GetPublic())
+		if err != nil {
+			return err
+		}
+		if rot.Salt != salt {
+			return fmt.Errorf("Rotation record is for salt %#v", rot.Salt)
+		}
+		path, err = rot.Path()
+	} else if compact {
+		path, err = osr.CompactPath(salt, sk.GetPublic())
+	} else {
+		path, err = osr.Path(salt, sk.GetPublic())
+	}
+	if err != nil {
+		return err
 	}
 
-	path, err := osr.Path(salt, sk.GetPublic())
+	rec.Order, err = orderFor(path)
 	if err != nil {
 		return err
 	}
 
-	fmt.Fprintf(os.Stderr, "Generated record: /iprs%s\n", path)
+	fmt.Fprintf(os.Stderr, "Generated record: %s\n", osr.Key(path))
 
-	data, err := rec.Encode(sk)
+	var data []byte
+	if compact {
+		data, err = rec.EncodeCompact(sk, codec)
+	} else {
+		data, err = rec.Encode(sk, codec)
+	}
 	if err != nil {
 		return err
 	}
 
+	return writeOutput(output, data)
+}
+
+// Default clock file, in the home directory
+func defaultClockFile() string {
+	home := os.Getenv("HOME")
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".ipfs-objects", "clock.json")
+}
+
+// Next order of the local clock for the record key. If seed is true, the
+// clock first observes the latest record found on the network. Records too far
+// in the future are an error unless adopt is true.
+func clockOrder(cfg Config, clockfile string, seed, adopt bool, key string) (uint64, error) {
+	var err error
+	clock := osr.NewClock()
+	if clockfile != "" {
+		clock, err = osr.OpenClock(clockfile)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	now := time.Now()
+	if seed {
+		net, err := clientNetwork(cfg, "")
+		if err != nil {
+			return 0, err
+		}
+
+		ctx, cancel := context.WithTimeout(contextWithSignal(context.Background()), 30*time.Second)
+		rec, err := fetchRecord(ctx, net, key)
+		cancel()
+		if err == osr.ErrNoValidRecord {
+			fmt.Fprintf(os.Stderr, "%s: no record found on the network\n", key)
+		} else if err != nil {
+			return 0, err
+		} else if err = clock.Observe(key, rec.Order, now); err == osr.ErrClockSkew && adopt {
+			fmt.Fprintf(os.Stderr, "%s: following order %d from the network: %s\n", key, rec.Order, err)
+			err = clock.Adopt(key, rec.Order)
+			if err != nil {
+				return 0, err
+			}
+		} else if err == osr.ErrClockSkew {
+			// A lower order would never replace that record
+			return 0, fmt.Errorf("%s: order %d from the network: %s, use -adopt to follow it", key, rec.Order, err)
+		} else if err != nil {
+			return 0, err
+		}
+	}
+
+	return clock.Next(key, now)
+}
+
+// Write data to the output file, or to stdout if output is empty
+func writeOutput(output string, data []byte) error {
+	var err error
 	out := os.Stdout
 	if output != "" {
 		out, err = os.Create(output)
@@ -65,3 +273,111 @@ func genosr(args []string) error {
 
 	return nil
 }
+
+func genmultiosr(rec osr.Record, signers []string, threshold uint32, keyfile string, codec osr.Codec, output string, orderFor func(path string) (uint64, error)) error {
+	var pks []ic.PubKey
+	for _, file := range signers {
+		pk, err := readPubKeyFile(file)
+		if err != nil {
+			return err
+		}
+		pks = append(pks, pk)
+	}
+
+	if threshold == 0 {
+		threshold = uint32(len(pks))
+	}
+
+	err := rec.SetPublicKeys(pks, threshold)
+	if err != nil {
+		return err
+	}
+
+	path, err := rec.Path()
+	if err != nil {
+		return err
+	}
+
+	rec.Order, err = orderFor(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Generated record: %s\n", osr.Key(path))
+
+	data, err := rec.EncodeMulti(codec)
+	if err != nil {
+		return err
+	}
+
+	if keyfile != "" {
+		sk, err := readKeyFile(keyfile)
+		if err != nil {
+			return err
+		}
+
+		data, err = osr.Sign(data, sk)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Collect %d signatures with sign-osr\n", threshold)
+
+	return writeOutput(output, data)
+}
+
+func signosr(args []string) error {
+	var f flag.FlagSet
+	var keyfile string
+	var output string
+	f.StringVar(&keyfile, "k", "", "Secret key file")
+	f.StringVar(&output, "o", "", "Output file (default: overwrite the record)")
+	f.Parse(args[1:])
+
+	if keyfile == "" {
+		return fmt.Errorf("Please specify a key file with -k")
+	}
+
+	recordFile := f.Arg(0)
+	if output == "" {
+		output = recordFile
+	}
+
+	sk, err := readKeyFile(keyfile)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(recordFile)
+	if err != nil {
+		return err
+	}
+
+	data, err = osr.Sign(data, sk)
+	if err != nil {
+		return err
+	}
+
+	_, err = osr.Decode(data)
+	if err == osr.ErrNotEnoughSignatures {
+		fmt.Fprintf(os.Stderr, "Signed, more signatures are needed\n")
+	} else if err != nil {
+		return err
+	} else {
+		fmt.Fprintf(os.Stderr, "Signed, the record is complete\n")
+	}
+
+	return writeOutput(output, data)
+}
+
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"ipobj"
+)
+
+// Decode and verify a record for key. If key is empty, it is computed from
+// the record by its record type.
+func decodeRecord(data []byte, key string) (ipobj.DecodedRecord, string, error) {
+	var t ipobj.RecordType
+	var err error
+	if key == "" {
+		t, key, err = ipobj.RecordTypeOf(data)
+	} else {
+		t, err = ipobj.RecordTypeFor(key)
+	}
+	if err != nil {
+		return nil, key, err
+	}
+	rec, err := t.Decode(key, data)
+	return rec, key, err
+}
+
+// Record order for log messages, for record types that have one
+func recordOrder(t ipobj.RecordType, rec ipobj.DecodedRecord) string {
+	if order := t.Order(rec); order != "" {
+		return fmt.Sprintf(" (%s)", order)
+	}
+	return ""
+}
+
+// Print a record as described by its record type
+func printRecord(name string, t ipobj.RecordType, rec ipobj.DecodedRecord) {
+	fmt.Printf("%s: valid record\n", name)
+	for _, line := range t.Describe(rec) {
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+// Parse a record key given as /<namespace>/... or as the record CID printed
+// by resolve and advertise
+func parseRecordKey(arg string) (string, error) {
+	if arg == "" || strings.HasPrefix(arg, "/") && !strings.HasPrefix(arg, "/ipfs/") {
+		return arg, nil
+	}
+	addr, err := ipobj.ParseObjAddr(arg)
+	if err != nil {
+		return "", fmt.Errorf("Invalid record key or CID %s: %s", arg, err)
+	}
+	return ipobj.RecordKey(addr)
+}
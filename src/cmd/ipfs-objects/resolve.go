@@ -9,18 +9,25 @@ import (
 
 	"ipobj"
 	ipnet "ipobj-net"
+	osr "ipobj-osr"
 
-	base58 "github.com/jbenet/go-base58"
 	ic "github.com/libp2p/go-libp2p-crypto"
-	ma "github.com/multiformats/go-multiaddr"
 )
 
 func resolve(cfg Config, args []string) error {
 	var f flag.FlagSet
 	var keyfile string
 	var timeout time.Duration
+	var readkeyfile string
+	var openkeyfile string
+	var witnessfiles stringList
+	var minWitnesses int
 	f.StringVar(&keyfile, "k", "", "Secret key file")
 	f.DurationVar(&timeout, "t", 0, "Timeout")
+	f.StringVar(&readkeyfile, "read-key", "", "Read key file to open sealed payloads")
+	f.StringVar(&openkeyfile, "open-key", "", "Secret key file to open payloads sealed to it")
+	f.Var(&witnessfiles, "witness", "Public key file of a known witness (repeatable)")
+	f.IntVar(&minWitnesses, "witnesses", 0, "Number of known witnesses required to accept a record (default: all)")
 	f.Parse(args[1:])
 
 	var err error
@@ -30,6 +37,37 @@ func resolve(cfg Config, args []string) error {
 	} else {
 		sk, err = readKeyFile(keyfile)
 	}
+	if err != nil {
+		return err
+	}
+
+	var readKey *[osr.ReadKeySize]byte
+	if readkeyfile != "" {
+		readKey, err = readReadKeyFile(readkeyfile)
+		if err != nil {
+			return err
+		}
+	}
+
+	var openKey ic.PrivKey
+	if openkeyfile != "" {
+		openKey, err = readKeyFile(openkeyfile)
+		if err != nil {
+			return err
+		}
+	}
+
+	var witnesses []ic.PubKey
+	for _, file := range witnessfiles {
+		pk, err := readPubKeyFile(file)
+		if err != nil {
+			return err
+		}
+		witnesses = append(witnesses, pk)
+	}
+	if minWitnesses == 0 {
+		minWitnesses = len(witnesses)
+	}
 
 	config := ipnet.NetworkConfig{
 		ClientOnly: true,
@@ -44,7 +82,7 @@ func resolve(cfg Config, args []string) error {
 		return err
 	}
 
-	fmt.Printf("Peer id: %s\n", base58.Encode(net.Id()))
+	fmt.Printf("Peer id: %s\n", ipobj.PeerIdString(net.Id()))
 	// list out our addresses
 	addrs, err := net.InterfaceListenAddresses()
 	if err != nil {
@@ -58,7 +96,12 @@ func resolve(cfg Config, args []string) error {
 	ctx := contextWithSignal(context.Background())
 	var wg sync.WaitGroup
 
-	for _, record := range f.Args() {
+	for _, arg := range f.Args() {
+		record, err := parseRecordKey(arg)
+		if err != nil {
+			return err
+		}
+
 		wg.Add(1)
 		go func(record string) {
 			defer wg.Done()
@@ -72,38 +115,93 @@ func resolve(cfg Config, args []string) error {
 			}
 			defer cancel()
 
+			t, err := ipobj.RecordTypeFor(record)
+			if err != nil {
+				fmt.Printf("%s: error: %v\n", record, err)
+				return
+			}
+
 			cid := ipobj.NewRecordObjAddr(record)
-			fmt.Printf("Record:     %s\nRecord CID: %s\n", record, base58.Encode(cid))
+			fmt.Printf("Record:     %s\nRecord CID: %s\n", record, cid)
 			peers, err := net.Providers(ctx2, cid)
 			if err != nil {
 				fmt.Printf("%s: error: %v\n", record, err)
 				return
 			}
 
+			// Best record among the responses and the peer it came from
+			var best ipobj.DecodedRecord
+			var bestPeer *ipobj.PeerInfo
+
 		loop:
 			for {
 				var p *ipobj.PeerInfo
 				select {
-				case <-ctx.Done():
+				case <-ctx2.Done():
 					break loop
 				case p = <-peers:
 					if p == nil {
-						fmt.Printf("%s: no provider\n", record)
 						break loop
 					}
 					break
 				}
 
-				fmt.Printf("%s: possible provider: %s\n", record, base58.Encode(p.Id))
-				for _, a := range p.Addrs {
-					fmt.Printf("  - %v\n", ma.Cast(a))
+				fmt.Printf("%s: possible provider: %s\n", record, p)
+				for _, a := range p.Multiaddrs() {
+					fmt.Printf("  - %s\n", a)
 				}
-				data, err := net.GetRecordFrom(ctx, p.Id, record)
+				data, err := net.GetRecordFrom(ctx2, p.Id, record)
 				if err != nil {
-					fmt.Printf("%s: error from %s: %v\n", record, base58.Encode(p.Id), err)
+					fmt.Printf("%s: error from %s: %v\n", record, p, err)
 					continue
 				}
-				fmt.Printf("%s: response from: %v\n\t%v\n", record, base58.Encode(p.Id), string(data))
+				decoded, err := t.Decode(record, data)
+				if err == ipobj.ErrExpired {
+					fmt.Printf("%s: expired record from %s\n", record, p)
+					continue
+				} else if err != nil {
+					fmt.Printf("%s: invalid record from %s: %v\n", record, p, err)
+					continue
+				}
+				if minWitnesses > 0 {
+					err = osr.RequireWitnesses(data, witnesses, minWitnesses)
+					if err != nil {
+						fmt.Printf("%s: record from %s rejected: %v\n", record, p, err)
+						continue
+					}
+				}
+				fmt.Printf("%s: response from %s%s\n", record, p, recordOrder(t, decoded))
+
+				if best != nil {
+					cmp, err := t.Compare(decoded, best)
+					if err != nil {
+						fmt.Printf("%s: record from %s: %v\n", record, p, err)
+						continue
+					} else if cmp <= 0 {
+						continue
+					}
+				}
+				best = decoded
+				bestPeer = p
+			}
+
+			if best == nil {
+				fmt.Printf("%s: no provider\n", record)
+				return
+			} else if t.IsRevoked(best) {
+				fmt.Printf("%s: REVOKED by %s%s\n", record, bestPeer, recordOrder(t, best))
+				return
+			}
+			printRecord(fmt.Sprintf("%s from %s", record, bestPeer), t, best)
+
+			// Sealed payloads are an OSR feature
+			if rec, ok := best.(*osr.Record); ok && rec.IsSealed() {
+				payload, err := openRecord(rec, readKey, openKey)
+				if err != nil {
+					fmt.Printf("  payload: %v\n", err)
+				} else {
+					fmt.Printf("  payload: %s\n", payload)
+				}
 			}
 		}(record)
 	}
@@ -111,3 +209,17 @@ func resolve(cfg Config, args []string) error {
 	wg.Wait()
 	return nil
 }
+
+// Open a sealed record with the read key or the recipient key, if given
+func openRecord(rec *osr.Record, readKey *[osr.ReadKeySize]byte, openKey ic.PrivKey) (string, error) {
+	if readKey != nil {
+		payload, err := rec.OpenWithKey(readKey)
+		if err == nil || openKey == nil {
+			return payload, err
+		}
+	}
+	if openKey != nil {
+		return rec.Open(openKey)
+	}
+	return "", osr.ErrSealed
+}
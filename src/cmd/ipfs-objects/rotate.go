@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	osr "ipobj-osr"
+)
+
+func genrotation(cfg Config, args []string) error {
+	var f flag.FlagSet
+	var keyfile string
+	var nextfile string
+	var order uint64
+	var output string
+	var salt string
+	var format string
+	var prev string
+	var clockfile string
+	var seed bool
+	var adopt bool
+	f.StringVar(&keyfile, "k", "", "Secret key file of the retired key")
+	f.StringVar(&nextfile, "next", "", "Public key file of the successor key")
+	f.StringVar(&output, "o", "", "Output file")
+	f.StringVar(&salt, "s", "", "Salt")
+	f.Uint64Var(&order, "n", 0, "Rotation order (default: next order of the local clock)")
+	f.StringVar(&format, "f", "json", "Record format (json, cbor, protobuf)")
+	f.StringVar(&prev, "r", "", "Rotation record of the retired key, if it is itself a successor")
+	f.StringVar(&clockfile, "clock", defaultClockFile(), "File keeping the last order of each record (empty to disable)")
+	f.BoolVar(&seed, "seed", false, "Seed the clock from the latest record found on the network")
+	f.BoolVar(&adopt, "adopt", false, "With -seed, follow the order found on the network even if it is too far in the future")
+	f.Parse(args[1:])
+
+	if keyfile == "" || nextfile == "" {
+		return fmt.Errorf("Please specify key files with -k and -next")
+	}
+
+	codec, err := osr.CodecByPath("/" + format)
+	if err != nil {
+		return fmt.Errorf("Unknown record format %s", format)
+	}
+
+	sk, err := readKeyFile(keyfile)
+	if err != nil {
+		return err
+	}
+
+	next, err := readPubKeyFile(nextfile)
+	if err != nil {
+		return err
+	}
+
+	var prevData []byte
+	var path string
+	if prev != "" {
+		prevData, err = ioutil.ReadFile(prev)
+		if err != nil {
+			return err
+		}
+		var rot *osr.Record
+		rot, err = osr.VerifyRotation(prevData, sk.GetPublic())
+		if err != nil {
+			return err
+		}
+		if rot.Salt != salt {
+			return fmt.Errorf("Rotation record is for salt %#v", rot.Salt)
+		}
+		path, err = rot.Path()
+	} else {
+		path, err = osr.Path(salt, sk.GetPublic())
+	}
+	if err != nil {
+		return err
+	}
+
+	if order == 0 {
+		order, err = clockOrder(cfg, clockfile, seed, adopt, osr.Key(path))
+		if err != nil {
+			return err
+		}
+	}
+
+	rec, err := osr.NewRotation(salt, next, order, prevData)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Generated rotation for: %s\n", osr.Key(path))
+
+	data, err := rec.Encode(sk, codec)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(output, data)
+}
+
+func verifyrotation(args []string) error {
+	var f flag.FlagSet
+	f.Parse(args[1:])
+
+	for _, file := range f.Args() {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		rec, err := osr.Decode(data)
+		if err != nil {
+			return fmt.Errorf("%s: %s", file, err)
+		}
+
+		next, err := rec.GetSuccessor()
+		if err != nil {
+			return fmt.Errorf("%s: %s", file, err)
+		}
+
+		path, err := rec.Path()
+		if err != nil {
+			return fmt.Errorf("%s: %s", file, err)
+		}
+
+		pk, err := rec.GetPublicKey()
+		if err != nil {
+			return fmt.Errorf("%s: %s", file, err)
+		}
+
+		fmt.Printf("%s: valid rotation for %s\n", file, osr.Key(path))
+		fmt.Printf("  from: %s\n", osr.KeyFingerprint(pk))
+		fmt.Printf("  to:   %s\n", osr.KeyFingerprint(next))
+	}
+
+	return nil
+}
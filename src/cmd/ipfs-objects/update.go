@@ -10,22 +10,26 @@ import (
 
 	"ipobj"
 	ipnet "ipobj-net"
-	osr "ipobj-osr"
 
-	base58 "github.com/jbenet/go-base58"
 	ic "github.com/libp2p/go-libp2p-crypto"
-	ma "github.com/multiformats/go-multiaddr"
 )
 
 func update(cfg Config, args []string) error {
 	var f flag.FlagSet
 	var keyfile string
 	var timeout time.Duration
+	var key string
 	f.StringVar(&keyfile, "k", "", "Secret key file")
 	f.DurationVar(&timeout, "t", 0, "Timeout")
+	f.StringVar(&key, "key", "", "Record key or CID, required for compact records and records other than OSRs")
 	f.Parse(args[1:])
 
 	var err error
+	key, err = parseRecordKey(key)
+	if err != nil {
+		return err
+	}
+
 	var sk ic.PrivKey
 	if keyfile == "" {
 		sk, err = dummySecretKey()
@@ -46,7 +50,7 @@ func update(cfg Config, args []string) error {
 		return err
 	}
 
-	fmt.Printf("Peer id: %s\n", base58.Encode(net.Id()))
+	fmt.Printf("Peer id: %s\n", ipobj.PeerIdString(net.Id()))
 	// list out our addresses
 	addrs, err := net.InterfaceListenAddresses()
 	if err != nil {
@@ -66,18 +70,13 @@ func update(cfg Config, args []string) error {
 		if err != nil {
 			return err
 		}
-		rec, err := osr.Decode(recordData)
-		if err != nil {
-			return err
-		}
-		recordKey, err := rec.Path()
+		rec, recordKey, err := decodeRecord(recordData, key)
 		if err != nil {
 			return err
 		}
-		recordKey = "/iprs" + recordKey
 
 		wg.Add(1)
-		go func(recordKey string, recordData []byte, rec *osr.Record) {
+		go func(recordKey string, recordData []byte, rec ipobj.DecodedRecord) {
 			defer wg.Done()
 			var ctx2 context.Context
 			var cancel context.CancelFunc
@@ -90,7 +89,7 @@ func update(cfg Config, args []string) error {
 			defer cancel()
 
 			cid := ipobj.NewRecordObjAddr(recordKey)
-			fmt.Printf("Record:     %s\nRecord CID: %s\n", recordKey, base58.Encode(cid))
+			fmt.Printf("Record:     %s\nRecord CID: %s\n", recordKey, cid)
 			peers, err := net.Providers(ctx2, cid)
 			if err != nil {
 				fmt.Printf("%s: error: %v\n", recordKey, err)
@@ -111,19 +110,19 @@ func update(cfg Config, args []string) error {
 					break
 				}
 
-				fmt.Printf("%s: possible provider: %s\n", recordKey, base58.Encode(p.Id))
-				for _, a := range p.Addrs {
-					fmt.Printf("  - %v\n", ma.Cast(a))
+				fmt.Printf("%s: possible provider: %s\n", recordKey, p)
+				for _, a := range p.Multiaddrs() {
+					fmt.Printf("  - %s\n", a)
 				}
 				data, err := net.GetRecordFrom(ctx, p.Id, recordKey)
 				if err != nil {
-					fmt.Printf("%s: error from %s: %v\n", recordKey, base58.Encode(p.Id), err)
+					fmt.Printf("%s: error from %s: %v\n", recordKey, p, err)
 					continue
 				}
-				fmt.Printf("%s: response from: %v\n", recordKey, base58.Encode(p.Id))
+				fmt.Printf("%s: response from: %v\n", recordKey, p)
 				err = updateRecord(ctx, net, recordKey, recordData, rec, p.Id, data)
 				if err != nil {
-					fmt.Printf("%s: error from %s: %s\n", recordKey, base58.Encode(p.Id), err)
+					fmt.Printf("%s: error from %s: %s\n", recordKey, p, err)
 				}
 			}
 		}(recordKey, recordData, rec)
@@ -132,29 +131,34 @@ func update(cfg Config, args []string) error {
 	return nil
 }
 
-func updateRecord(ctx context.Context, net *ipnet.Network, key string, baseRecData []byte, baseRec *osr.Record, peerId []byte, newRecData []byte) error {
-	newRec, err := osr.Decode(newRecData)
+func updateRecord(ctx context.Context, net *ipnet.Network, key string, baseRecData []byte, baseRec ipobj.DecodedRecord, peerId []byte, newRecData []byte) error {
+	t, err := ipobj.RecordTypeFor(key)
 	if err != nil {
 		return err
 	}
 
-	newKey, err := newRec.Path()
-	if err != nil {
+	newRec, err := t.Decode(key, newRecData)
+	if err == ipobj.ErrExpired {
+		fmt.Printf("%s: expired record from %s\n", key, ipobj.PeerIdString(peerId))
+		return net.UpdatePeerRecord(ctx, peerId, key, baseRecData)
+	} else if err != nil {
 		return err
 	}
 
-	if "/iprs"+newKey != key {
-		fmt.Errorf("Mismatching key: /iprs%s", newKey)
-	}
-
-	if newRec.Order == baseRec.Order {
-		fmt.Printf("%s: same record from %s\n", key, base58.Encode(peerId))
+	cmp, err := t.Compare(newRec, baseRec)
+	if err != nil {
+		return err
+	} else if cmp == 0 {
+		fmt.Printf("%s: same record from %s\n", key, ipobj.PeerIdString(peerId))
 		return nil
-	} else if newRec.Order > baseRec.Order {
-		fmt.Printf("%s: newer record from %s (%d)\n", key, base58.Encode(peerId), newRec.Order)
+	} else if cmp > 0 {
+		fmt.Printf("%s: newer record from %s%s\n", key, ipobj.PeerIdString(peerId), recordOrder(t, newRec))
 		return nil
 	}
-	fmt.Printf("%s: old record from %s (%d)\n", key, base58.Encode(peerId), newRec.Order)
+	fmt.Printf("%s: old record from %s%s\n", key, ipobj.PeerIdString(peerId), recordOrder(t, newRec))
+	if t.IsRevoked(baseRec) {
+		fmt.Printf("%s: send tombstone to %s\n", key, ipobj.PeerIdString(peerId))
+	}
 
 	return net.UpdatePeerRecord(ctx, peerId, key, baseRecData)
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	osr "ipobj-osr"
+)
+
+func witness(args []string) error {
+	var f flag.FlagSet
+	var keyfile string
+	var output string
+	var list bool
+	var key string
+	f.StringVar(&keyfile, "k", "", "Secret key file")
+	f.StringVar(&key, "key", "", "Record key or CID, required for compact records")
+	f.StringVar(&output, "o", "", "Output file (default: overwrite the record)")
+	f.BoolVar(&list, "l", false, "List the valid witnesses instead of adding one")
+	f.Parse(args[1:])
+
+	key, err := parseRecordKey(key)
+	if err != nil {
+		return err
+	}
+
+	recordFile := f.Arg(0)
+	if output == "" {
+		output = recordFile
+	}
+
+	data, err := ioutil.ReadFile(recordFile)
+	if err != nil {
+		return err
+	}
+
+	if list {
+		atts, err := osr.Witnesses(data)
+		if err != nil {
+			return err
+		}
+		for _, att := range atts {
+			fmt.Printf("%s\t%s\n", osr.KeyFingerprint(att.PublicKey), att.Time.UTC().Format(time.RFC3339))
+		}
+		return nil
+	}
+
+	if keyfile == "" {
+		return fmt.Errorf("Please specify a key file with -k")
+	}
+
+	sk, err := readKeyFile(keyfile)
+	if err != nil {
+		return err
+	}
+
+	// Only attest records that verify
+	if key == "" {
+		_, err = osr.Decode(data)
+	} else {
+		_, err = osr.DecodeForPath(data, key)
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err = osr.AddWitness(data, sk, time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Witnessed %s\n", recordFile)
+	return writeOutput(output, data)
+}
@@ -8,6 +8,9 @@ import (
 	"net"
 	"time"
 
+	// OSR record type
+	_ "ipobj-osr"
+
 	ds "github.com/ipfs/go-datastore"
 	exchange "github.com/ipfs/go-ipfs/exchange"
 	bitswap "github.com/ipfs/go-ipfs/exchange/bitswap"
@@ -21,6 +24,7 @@ import (
 	ipfs_peer "github.com/libp2p/go-libp2p-peer"
 	peer "github.com/libp2p/go-libp2p-peer"
 	pstore "github.com/libp2p/go-libp2p-peerstore"
+	record "github.com/libp2p/go-libp2p-record"
 	routing "github.com/libp2p/go-libp2p-routing"
 	swarm "github.com/libp2p/go-libp2p-swarm"
 	discovery "github.com/libp2p/go-libp2p/p2p/discovery"
@@ -144,6 +148,15 @@ func NewNetwork(ctx context.Context, config NetworkConfig, peerObj ipobj.Peer, s
 	}
 	client.DataHandler = &PeerRecord{peerObj}
 
+	// Validate and select the records of every registered record type
+	for _, t := range ipobj.RecordTypes() {
+		client.Validator[t.Namespace()] = &record.ValidChecker{
+			Func: ipobj.ValidateRecord,
+			Sign: false,
+		}
+		client.Selector[t.Namespace()] = ipobj.SelectRecord
+	}
+
 	// Bitswap Protocol
 	peerHost := ipfs_rhost.Wrap(host, client)
 	bitswapNetwork := ipfs_bsnet.NewFromIpfsHost(peerHost, client)
@@ -85,6 +85,10 @@ func (net *Network) GetObject(ctx context.Context, obj ipobj.ObjAddr) (io.Reader
 	return ipobj.BytesToReader(block.RawData()), nil
 }
 
+func (net *Network) GetDAG(ctx context.Context, obj ipobj.ObjAddr, maxSize int64) (io.Reader, error) {
+	return ipobj.NewDAGReader(ctx, net.GetObject, obj, maxSize)
+}
+
 func (net *Network) GetRecord(ctx context.Context, record string) <-chan *ipobj.Record {
 	records := net.client.GetValuesAsync(ctx, record, -1)
 	resChan := make(chan *ipobj.Record, 0)
@@ -0,0 +1,198 @@
+package osr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+// Batched records
+//
+// Many records of the same key can be signed at once. The batch root record
+// signs the root hash of a Merkle tree of the records and the number of
+// records, and has no salt or payload. Each record of the batch is then
+// encoded without public key or signature, with the encoded batch root
+// record, its index in the batch and the sibling hashes from its leaf to the
+// root. Decode verifies the batch root record and recomputes the root from
+// the record and its proof.
+//
+// Leaves are sha256(0x00 || record) over the canonical record bytes, nodes
+// are sha256(0x01 || left || right). A node without sibling, the last of a
+// level with an odd number of nodes, is promoted unchanged to the next
+// level.
+//
+// Batch root records cannot be published alone, and batches cannot be
+// signed by rotated or multiple keys.
+
+var ErrInvalidBatch error = errors.New("Invalid record batch")
+var ErrInvalidProof error = errors.New("Invalid batch inclusion proof")
+
+func (r *Record) IsBatch() bool {
+	return len(r.Batch) > 0
+}
+
+// Sign records in a single batch. Returns the encoded records in the same
+// order. Records must have distinct salts.
+func EncodeBatch(recs []*Record, sk ic.PrivKey, codec Codec) ([][]byte, error) {
+	if len(recs) == 0 {
+		return nil, ErrInvalidBatch
+	}
+
+	var leaves [][]byte
+	var hashes [][]byte
+	salts := map[string]bool{}
+	for _, rec := range recs {
+		if rec.Rotation != nil || rec.IsMulti() || rec.IsBatch() || salts[rec.Salt] {
+			return nil, ErrInvalidBatch
+		}
+		salts[rec.Salt] = true
+
+		var ur Record = *rec
+		ur.PublicKey = ""
+		ur.Version = RecordVersion
+		leaf, err := codec.EncodeRecord(&ur)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+		hashes = append(hashes, leafHash(leaf))
+	}
+
+	levels := merkleLevels(hashes)
+	root := Record{
+		Batch:     levels[len(levels)-1][0],
+		BatchSize: uint64(len(leaves)),
+	}
+	rootData, err := root.Encode(sk, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	var res [][]byte
+	for i, leaf := range leaves {
+		data, err := encodeEnvelope(codec, &SignedRecord{
+			Record: leaf,
+			Batch:  rootData,
+			Proof:  merkleProof(levels, i),
+			Index:  uint64(i),
+		})
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, data)
+	}
+	return res, nil
+}
+
+// Verify a batched record, taking its public key from the batch root record
+func decodeBatched(codec Codec, sr *SignedRecord, ur *Record, pk ic.PubKey, validity bool) (*Record, error) {
+	if ur.PublicKey != "" || ur.IsMulti() || ur.Rotation != nil || ur.IsBatch() {
+		return nil, ErrInvalidBatch
+	}
+
+	err := checkCanonical(codec, sr, ur)
+	if err != nil {
+		return nil, err
+	}
+
+	rcodec, rsr, root, err := decodeEnvelope(sr.Batch)
+	if err != nil {
+		return nil, err
+	} else if !root.IsBatch() || root.Rotation != nil || root.IsMulti() {
+		return nil, ErrInvalidBatch
+	}
+
+	err = root.check(rcodec, rsr, pk, validity)
+	if err != nil {
+		return nil, err
+	}
+
+	if !verifyProof(leafHash(sr.Record), sr.Index, root.BatchSize, sr.Proof, root.Batch) {
+		return nil, ErrInvalidProof
+	}
+
+	ur.PublicKey = root.PublicKey
+	ur.compact = root.compact
+	ur.tieBreak = sr.Record
+
+	if validity {
+		err = ur.Valid(time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ur, nil
+}
+
+func leafHash(rec []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0})
+	h.Write(rec)
+	return h.Sum(nil)
+}
+
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// Compute the levels of a Merkle tree, from the leaves to the root
+func merkleLevels(hashes [][]byte) [][][]byte {
+	levels := [][][]byte{hashes}
+	for len(hashes) > 1 {
+		var next [][]byte
+		for i := 0; i < len(hashes); i += 2 {
+			if i+1 < len(hashes) {
+				next = append(next, nodeHash(hashes[i], hashes[i+1]))
+			} else {
+				next = append(next, hashes[i])
+			}
+		}
+		levels = append(levels, next)
+		hashes = next
+	}
+	return levels
+}
+
+// Sibling hashes from leaf i to the root
+func merkleProof(levels [][][]byte, i int) [][]byte {
+	var proof [][]byte
+	for _, level := range levels[:len(levels)-1] {
+		if sibling := i ^ 1; sibling < len(level) {
+			proof = append(proof, level[sibling])
+		}
+		i /= 2
+	}
+	return proof
+}
+
+// Check the proof of a leaf at index i in a tree of size leaves
+func verifyProof(hash []byte, i, size uint64, proof [][]byte, root []byte) bool {
+	if i >= size {
+		return false
+	}
+
+	for n := size; n > 1; n = (n + 1) / 2 {
+		if i%2 == 1 || i+1 < n {
+			if len(proof) == 0 {
+				return false
+			}
+			if i%2 == 1 {
+				hash = nodeHash(proof[0], hash)
+			} else {
+				hash = nodeHash(hash, proof[0])
+			}
+			proof = proof[1:]
+		}
+		i /= 2
+	}
+
+	return len(proof) == 0 && bytes.Equal(hash, root)
+}
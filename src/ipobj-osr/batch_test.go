@@ -0,0 +1,87 @@
+package osr
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	sk := testKey(t)
+	for name, codec := range testCodecs {
+		// Sizes giving complete and incomplete Merkle trees
+		for size := 1; size <= 9; size++ {
+			var recs []*Record
+			for i := 0; i < size; i++ {
+				recs = append(recs, &Record{CID: testCID, Order: 1, Salt: fmt.Sprintf("s%d", i)})
+			}
+			data, err := EncodeBatch(recs, sk, codec)
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+
+			for i, d := range data {
+				dec, err := Decode(d)
+				if err != nil {
+					t.Fatalf("%s: record %d of %d: %s", name, i, size, err)
+				}
+				if dec.Salt != recs[i].Salt || dec.IsBatch() {
+					t.Errorf("%s: record %d of %d decoded as %+v", name, i, size, dec)
+				}
+				err = CheckStrict(d)
+				if err != nil {
+					t.Errorf("%s: record %d of %d: strict: %s", name, i, size, err)
+				}
+			}
+		}
+	}
+}
+
+func TestBatchForged(t *testing.T) {
+	sk := testKey(t)
+	for name, codec := range testCodecs {
+		var recs []*Record
+		for _, salt := range []string{"a", "b", "c", "d", "e"} {
+			recs = append(recs, &Record{CID: testCID, Order: 1, Salt: salt})
+		}
+		data, err := EncodeBatch(recs, sk, codec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, sr, _, err := decodeEnvelope(data[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, other, _, err := decodeEnvelope(data[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// A proof for another record must not verify
+		forged := *sr
+		forged.Proof = other.Proof
+		fdata, err := encodeEnvelope(codec, &forged)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Decode(fdata); err != ErrInvalidProof {
+			t.Errorf("%s: proof of another record: got %v", name, err)
+		}
+
+		// Nor the proof at another index
+		forged = *sr
+		forged.Index = 1
+		fdata, err = encodeEnvelope(codec, &forged)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Decode(fdata); err != ErrInvalidProof {
+			t.Errorf("%s: other index: got %v", name, err)
+		}
+
+		// Batch roots are not records on their own
+		if _, err := Decode(sr.Batch); err != ErrInvalidBatch {
+			t.Errorf("%s: batch root: got %v", name, err)
+		}
+	}
+}
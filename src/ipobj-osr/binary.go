@@ -0,0 +1,226 @@
+package osr
+
+import (
+	"encoding/base64"
+
+	proto "github.com/gogo/protobuf/proto"
+)
+
+// Wire structures shared by the binary codecs (CBOR and protobuf). Unlike
+// the JSON codec, binary fields are stored as raw bytes. The json tags give
+// the CBOR map keys, the protobuf tags follow this schema:
+//
+//	message Record {
+//		string cid  = 1;
+//		uint64 ord  = 2;
+//		bytes  pkey = 3;
+//		string salt = 4;
+//		uint64 nbf  = 5;
+//		uint64 eol  = 6;
+//		bytes  next = 7;
+//		bytes  rot  = 8;
+//		repeated bytes pkeys = 9;
+//		uint32 thr  = 10;
+//		bool   revoked = 11;
+//		bytes  prev = 12;
+//		uint32 v    = 13;
+//		repeated string salts = 14;
+//		bytes  enc  = 15;
+//		repeated bytes rcpt = 16;
+//		bytes  batch = 17;
+//		uint64 bsize = 18;
+//	}
+//
+//	message SignedRecord {
+//		bytes rec = 1;
+//		bytes sig = 2;
+//		repeated bytes sigs = 3;
+//		bytes batch = 4;
+//		repeated bytes proof = 5;
+//		uint64 idx = 6;
+//		repeated Witness wits = 7;
+//	}
+//
+//	message Witness {
+//		bytes  pkey = 1;
+//		uint64 t    = 2;
+//		bytes  sig  = 3;
+//	}
+
+type binRecord struct {
+	CID       string `json:"cid" protobuf:"bytes,1,opt,name=cid,proto3"`
+	Order     uint64 `json:"ord" protobuf:"varint,2,opt,name=ord,proto3"`
+	PublicKey []byte `json:"pkey" protobuf:"bytes,3,opt,name=pkey,proto3"`
+	Salt      string `json:"salt" protobuf:"bytes,4,opt,name=salt,proto3"`
+	NotBefore uint64 `json:"nbf,omitempty" protobuf:"varint,5,opt,name=nbf,proto3"`
+	EOL       uint64 `json:"eol,omitempty" protobuf:"varint,6,opt,name=eol,proto3"`
+	Successor []byte `json:"next,omitempty" protobuf:"bytes,7,opt,name=next,proto3"`
+	Rotation  []byte `json:"rot,omitempty" protobuf:"bytes,8,opt,name=rot,proto3"`
+
+	PublicKeys [][]byte `json:"pkeys,omitempty" protobuf:"bytes,9,rep,name=pkeys"`
+	Threshold  uint32   `json:"thr,omitempty" protobuf:"varint,10,opt,name=thr,proto3"`
+
+	Revoked bool   `json:"revoked,omitempty" protobuf:"varint,11,opt,name=revoked,proto3"`
+	Prev    []byte `json:"prev,omitempty" protobuf:"bytes,12,opt,name=prev,proto3"`
+	Version uint32 `json:"v,omitempty" protobuf:"varint,13,opt,name=v,proto3"`
+
+	Salts []string `json:"salts,omitempty" protobuf:"bytes,14,rep,name=salts"`
+
+	SealedCID  []byte   `json:"enc,omitempty" protobuf:"bytes,15,opt,name=enc,proto3"`
+	Recipients [][]byte `json:"rcpt,omitempty" protobuf:"bytes,16,rep,name=rcpt"`
+
+	Batch     []byte `json:"batch,omitempty" protobuf:"bytes,17,opt,name=batch,proto3"`
+	BatchSize uint64 `json:"bsize,omitempty" protobuf:"varint,18,opt,name=bsize,proto3"`
+}
+
+func (m *binRecord) Reset()         { *m = binRecord{} }
+func (m *binRecord) String() string { return proto.CompactTextString(m) }
+func (*binRecord) ProtoMessage()    {}
+
+type binSignedRecord struct {
+	Record     []byte   `json:"rec" protobuf:"bytes,1,opt,name=rec,proto3"`
+	Signature  []byte   `json:"sig,omitempty" protobuf:"bytes,2,opt,name=sig,proto3"`
+	Signatures [][]byte `json:"sigs,omitempty" protobuf:"bytes,3,rep,name=sigs"`
+
+	Batch []byte   `json:"batch,omitempty" protobuf:"bytes,4,opt,name=batch,proto3"`
+	Proof [][]byte `json:"proof,omitempty" protobuf:"bytes,5,rep,name=proof"`
+	Index uint64   `json:"idx,omitempty" protobuf:"varint,6,opt,name=idx,proto3"`
+
+	// Values rather than pointers, the CBOR decoder cannot fill slices of
+	// pointers
+	Witnesses []binWitness `json:"wits,omitempty" protobuf:"bytes,7,rep,name=wits"`
+}
+
+func (m *binSignedRecord) Reset()         { *m = binSignedRecord{} }
+func (m *binSignedRecord) String() string { return proto.CompactTextString(m) }
+func (*binSignedRecord) ProtoMessage()    {}
+
+type binWitness struct {
+	PublicKey []byte `json:"pkey" protobuf:"bytes,1,opt,name=pkey,proto3"`
+	Time      uint64 `json:"t" protobuf:"varint,2,opt,name=t,proto3"`
+	Signature []byte `json:"sig" protobuf:"bytes,3,opt,name=sig,proto3"`
+}
+
+func (m *binWitness) Reset()         { *m = binWitness{} }
+func (m *binWitness) String() string { return proto.CompactTextString(m) }
+func (*binWitness) ProtoMessage()    {}
+
+func toBinRecord(r *Record) (*binRecord, error) {
+	pk, err := base64.RawStdEncoding.DecodeString(r.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	next, err := base64.RawStdEncoding.DecodeString(r.Successor)
+	if err != nil {
+		return nil, err
+	}
+	var pks [][]byte
+	for _, key := range r.PublicKeys {
+		pkd, err := base64.RawStdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, err
+		}
+		pks = append(pks, pkd)
+	}
+	return &binRecord{
+		CID:       r.CID,
+		Order:     r.Order,
+		PublicKey: pk,
+		Salt:      r.Salt,
+		NotBefore: r.NotBefore,
+		EOL:       r.EOL,
+		Successor: next,
+		Rotation:  r.Rotation,
+
+		PublicKeys: pks,
+		Threshold:  r.Threshold,
+
+		Revoked: r.Revoked,
+		Prev:    r.Prev,
+		Version: r.Version,
+
+		Salts: r.Salts,
+
+		SealedCID:  r.SealedCID,
+		Recipients: r.Recipients,
+
+		Batch:     r.Batch,
+		BatchSize: r.BatchSize,
+	}, nil
+}
+
+func fromBinRecord(br *binRecord, r *Record) {
+	var pks []string
+	for _, pkd := range br.PublicKeys {
+		pks = append(pks, base64.RawStdEncoding.EncodeToString(pkd))
+	}
+	*r = Record{
+		CID:       br.CID,
+		Order:     br.Order,
+		PublicKey: base64.RawStdEncoding.EncodeToString(br.PublicKey),
+		Salt:      br.Salt,
+		NotBefore: br.NotBefore,
+		EOL:       br.EOL,
+		Successor: base64.RawStdEncoding.EncodeToString(br.Successor),
+		Rotation:  br.Rotation,
+
+		PublicKeys: pks,
+		Threshold:  br.Threshold,
+
+		Revoked: br.Revoked,
+		Prev:    br.Prev,
+		Version: br.Version,
+
+		Salts: br.Salts,
+
+		SealedCID:  br.SealedCID,
+		Recipients: br.Recipients,
+
+		Batch:     br.Batch,
+		BatchSize: br.BatchSize,
+	}
+}
+
+func toBinSigned(sr *SignedRecord) *binSignedRecord {
+	var wits []binWitness
+	for _, w := range sr.Witnesses {
+		wits = append(wits, binWitness{
+			PublicKey: w.PublicKey,
+			Time:      w.Time,
+			Signature: w.Signature,
+		})
+	}
+	return &binSignedRecord{
+		Record:     sr.Record,
+		Signature:  sr.Signature,
+		Signatures: sr.Signatures,
+
+		Batch: sr.Batch,
+		Proof: sr.Proof,
+		Index: sr.Index,
+
+		Witnesses: wits,
+	}
+}
+
+func fromBinSigned(bsr *binSignedRecord, sr *SignedRecord) {
+	var wits []Witness
+	for _, w := range bsr.Witnesses {
+		wits = append(wits, Witness{
+			PublicKey: w.PublicKey,
+			Time:      w.Time,
+			Signature: w.Signature,
+		})
+	}
+	*sr = SignedRecord{
+		Record:     bsr.Record,
+		Signature:  bsr.Signature,
+		Signatures: bsr.Signatures,
+
+		Batch: bsr.Batch,
+		Proof: bsr.Proof,
+		Index: bsr.Index,
+
+		Witnesses: wits,
+	}
+}
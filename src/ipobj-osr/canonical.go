@@ -0,0 +1,261 @@
+package osr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Canonical serialization
+//
+// Starting with version 1 (the "v" field), the signed record bytes must be
+// the canonical serialization of the record for its codec, so any
+// implementation can re-encode a record byte for byte. Decode rejects
+// records that are not canonical.
+//
+// JSON: a single object with no insignificant whitespace and keys sorted
+// by byte order. Fields with a zero value are omitted, except "cid", "ord",
+// "pkey" and "salt". Integers are written in decimal with no sign, leading
+// zero, fraction or exponent. Strings are UTF-8, escaping '"' and '\' as
+// '\"' and '\\', control characters as "\n", "\r", "\t" or "\u00xx", and '<',
+// '>', '&', U+2028 and U+2029 as "\u003c", "\u003e", "\u0026", "\u2028" and
+// "\u2029" (the characters the JSON envelope escapes), with lowercase
+// hexadecimal. "pkey", "next" and "pkeys" are unpadded standard base64,
+// "rot", "prev", "enc" and "batch" are padded standard base64. "pkeys",
+// "salts" and "rcpt" are arrays of strings.
+//
+// CBOR: the canonical CBOR of RFC 7049 section 3.9. The record is a map
+// with text string keys (the same as JSON) sorted by length first, then by
+// byte order. Integers and lengths use their shortest encoding, binary
+// fields are byte strings. Zero fields are omitted like in JSON.
+//
+// Protobuf: fields in ascending field number order, with zero values
+// omitted.
+
+// Current record version
+const RecordVersion = 1
+
+var ErrUnsupportedVersion error = errors.New("Unsupported record version")
+var ErrNotCanonical error = errors.New("Record is not in canonical form")
+
+// Canonical JSON encoding of v
+func canonicalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// json.Number keeps integers untouched
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err = dec.Decode(&generic)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = writeCanonicalJSON(&buf, generic)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCanonicalJSON(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		var keys []string
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(':')
+			err := writeCanonicalJSON(buf, v[k])
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			err := writeCanonicalJSON(buf, item)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case string:
+		writeCanonicalString(buf, v)
+	case json.Number:
+		buf.WriteString(string(v))
+	case bool:
+		if v {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case nil:
+		buf.WriteString("null")
+	default:
+		return fmt.Errorf("Cannot encode %T to canonical JSON", v)
+	}
+	return nil
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == '\n':
+			buf.WriteString("\\n")
+		case c == '\r':
+			buf.WriteString("\\r")
+		case c == '\t':
+			buf.WriteString("\\t")
+		case c < 0x20 || c == '<' || c == '>' || c == '&':
+			fmt.Fprintf(buf, "\\u%04x", c)
+		case c == 0xe2 && strings.HasPrefix(s[i:], "\u2028"):
+			buf.WriteString("\\u2028")
+			i += 2
+		case c == 0xe2 && strings.HasPrefix(s[i:], "\u2029"):
+			buf.WriteString("\\u2029")
+			i += 2
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte('"')
+}
+
+type cborEntry struct {
+	key   []byte
+	value []byte
+}
+
+// Canonical CBOR encoding of a struct pointer, using json tags as keys
+func canonicalCBOR(v interface{}) ([]byte, error) {
+	val := reflect.Indirect(reflect.ValueOf(v))
+	typ := val.Type()
+
+	var entries []cborEntry
+	for i := 0; i < typ.NumField(); i++ {
+		tag := strings.Split(typ.Field(i).Tag.Get("json"), ",")
+		if tag[0] == "" || tag[0] == "-" {
+			continue
+		}
+
+		field := val.Field(i)
+		if len(tag) > 1 && tag[1] == "omitempty" && isZero(field) {
+			continue
+		}
+
+		value, err := cborValue(field)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, cborEntry{cborString(3, []byte(tag[0])), value})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].key, entries[j].key
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return bytes.Compare(a, b) < 0
+	})
+
+	res := cborHeader(5, uint64(len(entries)))
+	for _, e := range entries {
+		res = append(res, e.key...)
+		res = append(res, e.value...)
+	}
+	return res, nil
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	}
+	return false
+}
+
+func cborValue(v reflect.Value) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return cborString(3, []byte(v.String())), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return []byte{0xf5}, nil
+		}
+		return []byte{0xf4}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cborHeader(0, v.Uint()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return cborString(2, v.Bytes()), nil
+		}
+		res := cborHeader(4, uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			item, err := cborValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, item...)
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("Cannot encode %s to canonical CBOR", v.Type())
+}
+
+func cborString(major byte, data []byte) []byte {
+	return append(cborHeader(major, uint64(len(data))), data...)
+}
+
+// CBOR item header with the shortest encoding of n
+func cborHeader(major byte, n uint64) []byte {
+	major = major << 5
+	switch {
+	case n < 24:
+		return []byte{major | byte(n)}
+	case n <= 0xff:
+		return []byte{major | 24, byte(n)}
+	case n <= 0xffff:
+		buf := []byte{major | 25, 0, 0}
+		binary.BigEndian.PutUint16(buf[1:], uint16(n))
+		return buf
+	case n <= 0xffffffff:
+		buf := []byte{major | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(buf[1:], uint32(n))
+		return buf
+	default:
+		buf := []byte{major | 27, 0, 0, 0, 0, 0, 0, 0, 0}
+		binary.BigEndian.PutUint64(buf[1:], n)
+		return buf
+	}
+}
@@ -0,0 +1,152 @@
+package osr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestCanonicalVectors(t *testing.T) {
+	rec := &Record{
+		CID:       "/ipfs/x",
+		Order:     300,
+		PublicKey: "AAEC",
+		Salt:      "a\"b\n",
+		Prev:      []byte{1, 2, 3},
+		Salts:     []string{"z"},
+		Version:   1,
+	}
+
+	vectors := map[string]string{
+		"json": hex.EncodeToString([]byte(`{"cid":"/ipfs/x","ord":300,"pkey":"AAEC","prev":"AQID","salt":"a\"b\n","salts":["z"],"v":1}`)),
+		"cbor": "a7" + "617601" + "63636964672f697066732f78" + "636f726419012c" +
+			"64706b657943000102" + "6470726576430102" + "03" + "6473616c74646122620a" +
+			"6573616c747381617a",
+		"protobuf": "0a072f697066732f78" + "10ac02" + "1a03000102" + "22046122620a" +
+			"6203010203" + "6801" + "72017a",
+	}
+
+	for name, codec := range testCodecs {
+		data, err := codec.EncodeRecord(rec)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if hex.EncodeToString(data) != vectors[name] {
+			t.Errorf("%s: got %x, expected %s", name, data, vectors[name])
+		}
+
+		var dec Record
+		err = codec.DecodeRecord(data, &dec)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		again, err := codec.EncodeRecord(&dec)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		} else if !bytes.Equal(again, data) {
+			t.Errorf("%s: re-encoded as %x", name, again)
+		}
+	}
+}
+
+func TestCanonicalHTMLCharacters(t *testing.T) {
+	sk := testKey(t)
+	rec := Record{CID: "/ipfs/x", Order: 1, Salt: "<a&b>\u2028", Version: RecordVersion}
+
+	// Escaped the way the JSON envelope would
+	data, err := JSONCodec.EncodeRecord(&rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"cid":"/ipfs/x","ord":1,"pkey":"","salt":"\u003ca\u0026b\u003e\u2028","v":1}`
+	if string(data) != expected {
+		t.Errorf("got %s, expected %s", data, expected)
+	}
+
+	for name, codec := range testCodecs {
+		r := rec
+		data, err := r.Encode(sk, codec)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		dec, err := Decode(data)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+		} else if dec.Salt != rec.Salt {
+			t.Errorf("%s: salt %q, expected %q", name, dec.Salt, rec.Salt)
+		}
+	}
+}
+
+// Sign bytes altered from the canonical form of rec
+func testSignAltered(t *testing.T, rec *Record, codec Codec, alter func([]byte) []byte) []byte {
+	sk := testKey(t)
+	pk, err := sk.GetPublic().Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ur Record = *rec
+	ur.PublicKey = base64.RawStdEncoding.EncodeToString(pk)
+
+	urd, err := codec.EncodeRecord(&ur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	urd = alter(urd)
+	sig, err := sk.Sign(urd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := encodeEnvelope(codec, &SignedRecord{Record: urd, Signature: sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestNotCanonical(t *testing.T) {
+	rec := &Record{CID: testCID, Order: 1, Salt: "canon", Version: RecordVersion}
+
+	cases := map[string]struct {
+		codec Codec
+		alter func([]byte) []byte
+	}{
+		"json key order": {JSONCodec, func(d []byte) []byte {
+			// The envelope compacts whitespace, move the salt first instead
+			d = bytes.Replace(d, []byte(`,"salt":"canon"`), nil, 1)
+			return append([]byte(`{"salt":"canon",`), d[1:]...)
+		}},
+		"cbor long integer": {CBORCodec, func(d []byte) []byte {
+			// "v": 1 as a one byte argument instead of inline
+			return bytes.Replace(d, []byte{0x61, 'v', 0x01}, []byte{0x61, 'v', 0x18, 0x01}, 1)
+		}},
+		"protobuf field order": {ProtobufCodec, func(d []byte) []byte {
+			// Move the first field, the CID, to the end
+			l := int(d[1]) + 2
+			return append(append([]byte{}, d[l:]...), d[:l]...)
+		}},
+	}
+
+	for name, c := range cases {
+		data := testSignAltered(t, rec, c.codec, c.alter)
+		if _, err := Decode(data); err != ErrNotCanonical {
+			t.Errorf("%s: got %v, expected ErrNotCanonical", name, err)
+		}
+	}
+
+	// Version 0 records predate canonical serialization
+	legacy := *rec
+	legacy.Version = 0
+	data := testSignAltered(t, &legacy, JSONCodec, cases["json key order"].alter)
+	if _, err := Decode(data); err != nil {
+		t.Errorf("legacy record: %s", err)
+	}
+
+	future := *rec
+	future.Version = RecordVersion + 1
+	data = testSignAltered(t, &future, CBORCodec, func(d []byte) []byte { return d })
+	if _, err := Decode(data); err != ErrUnsupportedVersion {
+		t.Errorf("future version: got %v, expected ErrUnsupportedVersion", err)
+	}
+}
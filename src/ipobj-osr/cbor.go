@@ -0,0 +1,55 @@
+package osr
+
+import (
+	"bytes"
+
+	"github.com/multiformats/go-multicodec"
+	cbor "github.com/whyrusleeping/cbor/go"
+)
+
+var HeaderCBOR = multicodec.Header([]byte("/cbor"))
+
+// CBOR codec
+var CBORCodec Codec = cborCodec{}
+
+type cborCodec struct{}
+
+func (cborCodec) Header() []byte {
+	return HeaderCBOR
+}
+
+func (cborCodec) EncodeRecord(r *Record) ([]byte, error) {
+	br, err := toBinRecord(r)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalCBOR(br)
+}
+
+func (cborCodec) DecodeRecord(data []byte, r *Record) error {
+	var br binRecord
+	err := cbor.NewDecoder(bytes.NewReader(data)).Decode(&br)
+	if err != nil {
+		return err
+	}
+	// Empty byte strings stand for absent fields
+	if len(br.Rotation) == 0 {
+		br.Rotation = nil
+	}
+	fromBinRecord(&br, r)
+	return nil
+}
+
+func (cborCodec) EncodeSigned(sr *SignedRecord) ([]byte, error) {
+	return cbor.Dumps(toBinSigned(sr))
+}
+
+func (cborCodec) DecodeSigned(data []byte, sr *SignedRecord) error {
+	var bsr binSignedRecord
+	err := cbor.NewDecoder(bytes.NewReader(data)).Decode(&bsr)
+	if err != nil {
+		return err
+	}
+	fromBinSigned(&bsr, sr)
+	return nil
+}
@@ -0,0 +1,133 @@
+package osr
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Hybrid logical clock
+//
+// Orders generated by Clock hold the wall clock time in milliseconds in their
+// high 48 bits and a logical counter in their low 16 bits. Each new order is
+// the current time, or the last order for the same record key plus one if the
+// clock did not move forward, so orders are strictly increasing even for
+// records published within the same millisecond or after the clock went
+// backwards. They are greater than the Unix timestamps in seconds used as
+// orders by earlier records.
+
+const clockCounterBits = 16
+
+// Maximum difference between the local clock and the time of an observed
+// order
+const MaxClockSkew = time.Hour
+
+var ErrClockSkew error = errors.New("Record order too far in the future")
+
+// Order for the wall clock time t, with a zero counter
+func ClockOrder(t time.Time) uint64 {
+	ms := t.UnixNano() / int64(time.Millisecond)
+	return uint64(ms) << clockCounterBits
+}
+
+// Wall clock time of an order generated by a Clock
+func ClockTime(order uint64) time.Time {
+	ms := int64(order >> clockCounterBits)
+	return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))
+}
+
+// Clock generates record orders and remembers the last order of each record
+// key. Clocks opened from a file save their state after each change.
+type Clock struct {
+	lock sync.Mutex
+	file string
+	last map[string]uint64
+}
+
+// New clock without persistence
+func NewClock() *Clock {
+	return &Clock{last: map[string]uint64{}}
+}
+
+// Open a clock persisted in file. The file is created on the first change.
+func OpenClock(file string) (*Clock, error) {
+	c := NewClock()
+	c.file = file
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &c.last)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Generate the next order for the record key at time now
+func (c *Clock) Next(key string, now time.Time) (uint64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	order := ClockOrder(now)
+	if last := c.last[key]; order <= last {
+		order = last + 1
+	}
+	c.last[key] = order
+	return order, c.save()
+}
+
+// Record an order seen for key, for example the order of the latest record
+// found on the network, so the next order is greater. Orders more than
+// MaxClockSkew ahead of now are ignored and return ErrClockSkew, see Adopt.
+func (c *Clock) Observe(key string, order uint64, now time.Time) error {
+	if order > ClockOrder(now.Add(MaxClockSkew)) {
+		return ErrClockSkew
+	}
+	return c.Adopt(key, order)
+}
+
+// Record an order seen for key as Observe does, however far ahead it is. The
+// next orders for key follow it, ahead of the wall clock.
+func (c *Clock) Adopt(key string, order uint64) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if order <= c.last[key] {
+		return nil
+	}
+	c.last[key] = order
+	return c.save()
+}
+
+// Write the clock file, must be called with the lock held
+func (c *Clock) save() error {
+	if c.file == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(c.last)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(c.file), 0700)
+	if err != nil {
+		return err
+	}
+
+	tmp := c.file + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.file)
+}
@@ -0,0 +1,118 @@
+package osr
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClockNext(t *testing.T) {
+	c := NewClock()
+	now := time.Now()
+
+	var last uint64
+	for i := 0; i < 10; i++ {
+		order, err := c.Next("key", now)
+		if err != nil {
+			t.Fatal(err)
+		} else if order <= last {
+			t.Fatalf("order %d after %d", order, last)
+		}
+		last = order
+	}
+
+	// The clock going backwards keeps orders increasing
+	order, err := c.Next("key", now.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	} else if order <= last {
+		t.Errorf("order %d after %d", order, last)
+	}
+
+	// Keys have their own counter
+	order, err = c.Next("other", now)
+	if err != nil {
+		t.Fatal(err)
+	} else if order != ClockOrder(now) {
+		t.Errorf("order %d, expected %d", order, ClockOrder(now))
+	}
+
+	if ClockTime(order).Unix() != now.Unix() {
+		t.Errorf("order time %v, expected %v", ClockTime(order), now)
+	}
+	if order <= uint64(now.Unix()) {
+		t.Errorf("order %d not greater than the Unix time", order)
+	}
+}
+
+func TestClockObserve(t *testing.T) {
+	c := NewClock()
+	now := time.Now()
+
+	ahead := ClockOrder(now.Add(time.Minute))
+	err := c.Observe("key", ahead, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := c.Next("key", now)
+	if err != nil {
+		t.Fatal(err)
+	} else if order != ahead+1 {
+		t.Errorf("order %d, expected %d", order, ahead+1)
+	}
+
+	skewed := ClockOrder(now.Add(2 * MaxClockSkew))
+	err = c.Observe("key", skewed, now)
+	if err != ErrClockSkew {
+		t.Fatalf("got %v, expected ErrClockSkew", err)
+	}
+	order, err = c.Next("key", now)
+	if err != nil {
+		t.Fatal(err)
+	} else if order >= skewed {
+		t.Errorf("skewed order %d was observed", skewed)
+	}
+
+	err = c.Adopt("key", skewed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err = c.Next("key", now)
+	if err != nil {
+		t.Fatal(err)
+	} else if order != skewed+1 {
+		t.Errorf("order %d, expected %d", order, skewed+1)
+	}
+}
+
+func TestClockFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "clock.json")
+
+	c, err := OpenClock(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	last, err := c.Next("key", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = OpenClock(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := c.Next("key", now)
+	if err != nil {
+		t.Fatal(err)
+	} else if order != last+1 {
+		t.Errorf("order %d, expected %d", order, last+1)
+	}
+}
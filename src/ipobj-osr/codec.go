@@ -0,0 +1,77 @@
+package osr
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/multiformats/go-multicodec"
+)
+
+// A Codec serializes records and their signature envelope. Codecs are
+// identified on the wire by their multicodec header, which is written right
+// after HeaderOSR.
+type Codec interface {
+	// Multicodec header for this codec
+	Header() []byte
+
+	// Serialize the unsigned record, the result is what gets signed
+	EncodeRecord(r *Record) ([]byte, error)
+	DecodeRecord(data []byte, r *Record) error
+
+	// Serialize the signed envelope
+	EncodeSigned(sr *SignedRecord) ([]byte, error)
+	DecodeSigned(data []byte, sr *SignedRecord) error
+}
+
+// SignedRecord is the codec independant signed envelope: the serialized
+// record as it was signed, and its signature. Multi-signature records have
+// one signature slot per public key instead, empty for missing signatures.
+// Batched records have no signature but the encoded batch root record, the
+// record index in the batch and its Merkle inclusion proof. Witnesses are
+// third party signatures attesting the record was seen, see AddWitness.
+type SignedRecord struct {
+	Record     []byte
+	Signature  []byte
+	Signatures [][]byte
+
+	Batch []byte
+	Proof [][]byte
+	Index uint64
+
+	Witnesses []Witness
+}
+
+var ErrUnknownCodec error = errors.New("Unknown OSR codec")
+
+var codecs = map[string]Codec{}
+
+// Register a codec so Decode can recognize its header. Registering a codec
+// with the same header as an existing one replaces it.
+func RegisterCodec(c Codec) {
+	codecs[string(c.Header())] = c
+}
+
+// Find a registered codec given its multicodec path (such as "/json")
+func CodecByPath(path string) (Codec, error) {
+	c, ok := codecs[string(multicodec.Header([]byte(path)))]
+	if !ok {
+		return nil, ErrUnknownCodec
+	}
+	return c, nil
+}
+
+// Find the codec whose header prefixes data. Returns nil if none match.
+func codecFor(data []byte) Codec {
+	for _, c := range codecs {
+		if bytes.HasPrefix(data, c.Header()) {
+			return c
+		}
+	}
+	return nil
+}
+
+func init() {
+	RegisterCodec(JSONCodec)
+	RegisterCodec(CBORCodec)
+	RegisterCodec(ProtobufCodec)
+}
@@ -0,0 +1,104 @@
+package osr
+
+import (
+	"errors"
+	"strings"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// Compact records
+//
+// Small public keys such as ed25519 or secp256k1 keys can be inlined in the
+// record path using an identity multihash instead of a hash of the key, the
+// same way libp2p peer IDs inline them. The record body then omits its public key
+// which is recovered from the path when decoding with DecodeForPath or
+// provided with DecodeWithKey. Compact records have a path distinct from the
+// regular path of the same key.
+//
+// Rotated and multi-signature records cannot be compact.
+
+// Maximum size of a marshalled public key that can be inlined in a path
+const MaxInlineKeySize = 42
+
+var ErrMissingKey error = errors.New("Record public key is missing")
+var ErrNotInline error = errors.New("Public key is not inlined in path")
+var ErrCannotInline error = errors.New("Public key cannot be inlined")
+
+// Check if pk is small enough to be inlined in a record path
+func CanInline(pk ic.PubKey) bool {
+	data, err := pk.Bytes()
+	return err == nil && len(data) <= MaxInlineKeySize
+}
+
+// Path of a compact record, with the public key inlined
+func CompactPath(salt string, pk ic.PubKey) (string, error) {
+	data, err := pk.Bytes()
+	if err != nil {
+		return "", err
+	} else if len(data) > MaxInlineKeySize {
+		return "", ErrCannotInline
+	}
+
+	hash, err := mh.Encode(data, mh.ID)
+	if err != nil {
+		return "", err
+	}
+	return PathFromHash(hash, salt)
+}
+
+// Public key inlined in a record path, with or without the /iprs prefix.
+// Returns ErrNotInline if the path only holds a hash of the key.
+func PublicKeyFromPath(path string) (ic.PubKey, error) {
+	hash, _, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dec, err := mh.Decode(hash)
+	if err != nil {
+		return nil, err
+	} else if dec.Code != mh.ID {
+		return nil, ErrNotInline
+	}
+
+	return ic.UnmarshalPublicKey(dec.Digest)
+}
+
+// Sign a compact record with sk, leaving the public key out of the record.
+// The record must be published at CompactPath.
+func (r *Record) EncodeCompact(sk ic.PrivKey, codec Codec) ([]byte, error) {
+	if r.Rotation != nil || r.IsMulti() || !CanInline(sk.GetPublic()) {
+		return nil, ErrCannotInline
+	}
+
+	var ur Record = *r
+	ur.PublicKey = ""
+	return ur.sign(sk, codec)
+}
+
+// Decode a record and check it is published at path, with or without the
+// /iprs prefix. The public key of compact records is taken from the path.
+func DecodeForPath(rec []byte, path string) (*Record, error) {
+	pk, err := PublicKeyFromPath(path)
+	if err == ErrNotInline {
+		pk = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	r, err := decode(rec, pk, true)
+	if err != nil {
+		return nil, err
+	}
+
+	recPath, err := r.Path()
+	if err != nil {
+		return nil, err
+	} else if recPath != strings.TrimPrefix(path, "/"+Namespace) {
+		return nil, ErrKeyMismatch
+	}
+
+	return r, nil
+}
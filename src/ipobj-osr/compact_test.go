@@ -0,0 +1,82 @@
+package osr
+
+import (
+	"crypto/rand"
+	"testing"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+func TestCompactPath(t *testing.T) {
+	sk, other := testKey(t), testKey(t)
+	rsa, _, err := ic.GenerateKeyPairWithReader(ic.RSA, 2048, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, salt := range []string{"", "web", "projects/web"} {
+		path, err := CompactPath(salt, sk.GetPublic())
+		if err != nil {
+			t.Fatal(err)
+		}
+		regular, err := Path(salt, sk.GetPublic())
+		if err != nil {
+			t.Fatal(err)
+		} else if path == regular {
+			t.Errorf("%q: compact path is the regular path", salt)
+		}
+
+		for _, p := range []string{path, Key(path)} {
+			pk, err := PublicKeyFromPath(p)
+			if err != nil {
+				t.Errorf("%s: %s", p, err)
+			} else if !pk.Equals(sk.GetPublic()) {
+				t.Errorf("%s: inlined another key", p)
+			}
+		}
+		if _, err := PublicKeyFromPath(regular); err != ErrNotInline {
+			t.Errorf("%s: got %v, expected ErrNotInline", regular, err)
+		}
+
+		rec := &Record{CID: testCID, Order: 1, Salt: salt}
+		data, err := rec.EncodeCompact(sk, CBORCodec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cases := []struct {
+			path string
+			err  error
+		}{
+			{path, nil},
+			{Key(path), nil},
+			{regular, ErrMissingKey},
+		}
+		for _, c := range cases {
+			dec, err := DecodeForPath(data, c.path)
+			if err != c.err {
+				t.Errorf("%s: got %v, expected %v", c.path, err, c.err)
+			} else if err != nil {
+				continue
+			}
+			if p, err := dec.Path(); err != nil || p != path {
+				t.Errorf("%s: decoded at %s (%v)", c.path, p, err)
+			}
+		}
+
+		otherPath, err := CompactPath(salt, other.GetPublic())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DecodeForPath(data, otherPath); err == nil {
+			t.Errorf("%q: decoded with the key of another path", salt)
+		}
+	}
+
+	if _, err := CompactPath("web", rsa.GetPublic()); err != ErrCannotInline {
+		t.Errorf("rsa key: got %v, expected ErrCannotInline", err)
+	}
+	if _, err := (&Record{CID: testCID, Order: 1}).EncodeCompact(rsa, CBORCodec); err != ErrCannotInline {
+		t.Errorf("rsa record: got %v, expected ErrCannotInline", err)
+	}
+}
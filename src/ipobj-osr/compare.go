@@ -0,0 +1,95 @@
+package osr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+)
+
+var ErrIncomparable error = errors.New("Records have different keys or salts")
+
+// Compare two records of the same key and salt. It returns -1 if a is older
+// than b, 1 if a is newer than b and 0 if they are the same record.
+//
+// The first rotation of a key is pinned: when a and b depend on different
+// rotations from the same key, the record depending on the rotation with the
+// lowest Order wins and competing rotations are never selected. Records
+// signed by a successor key win over records signed by the keys it replaced.
+// Tombstones win over normal records of the same key, so a successor key can
+// still publish after a retired key revoked the record. Then records are
+// ordered by Order.
+// Different records with the same Order are ordered by the hash of their
+// signature so every peer picks the same winner. Records should come from
+// Decode for this to work.
+func Compare(a, b *Record) (int, error) {
+	pa, err := a.Path()
+	if err != nil {
+		return 0, err
+	}
+
+	pb, err := b.Path()
+	if err != nil {
+		return 0, err
+	}
+
+	if pa != pb {
+		return 0, ErrIncomparable
+	}
+
+	// Lower order rotations win, hence the reversed result
+	cmp := comparePins(a.pins(), b.pins())
+	if cmp != 0 {
+		return -cmp, nil
+	}
+
+	if a.depth < b.depth {
+		return -1, nil
+	} else if a.depth > b.depth {
+		return 1, nil
+	}
+
+	if !a.Revoked && b.Revoked {
+		return -1, nil
+	} else if a.Revoked && !b.Revoked {
+		return 1, nil
+	}
+
+	return compareOrder(a, b), nil
+}
+
+// Order records by Order then by the hash of their tie break bytes
+func compareOrder(a, b *Record) int {
+	if a.Order < b.Order {
+		return -1
+	} else if a.Order > b.Order {
+		return 1
+	}
+
+	ha := sha256.Sum256(a.tieBreak)
+	hb := sha256.Sum256(b.tieBreak)
+	return bytes.Compare(ha[:], hb[:])
+}
+
+// Rotations a record depends on from the root key, including itself if it
+// is a rotation record
+func (r *Record) pins() []*Record {
+	var pins []*Record
+	if r.IsRotation() {
+		pins = append(pins, r)
+	}
+	for rot := r.rotation; rot != nil; rot = rot.rotation {
+		pins = append([]*Record{rot}, pins...)
+	}
+	return pins
+}
+
+// Compare the first rotations two chains disagree on, 0 if one chain
+// extends the other
+func comparePins(a, b []*Record) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if !bytes.Equal(a[i].tieBreak, b[i].tieBreak) {
+			return compareOrder(a[i], b[i])
+		}
+	}
+	return 0
+}
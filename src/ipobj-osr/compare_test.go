@@ -0,0 +1,63 @@
+package osr
+
+import (
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	sk, other := testKey(t), testKey(t)
+
+	older := testRecord(t, &Record{CID: testCID, Order: 1, Salt: "cmp"}, sk)
+	newer := testRecord(t, &Record{CID: testCID, Order: 2, Salt: "cmp"}, sk)
+	same := testRecord(t, &Record{CID: testCID, Order: 2, Salt: "cmp"}, sk)
+	tie := testRecord(t, &Record{CID: "/ipfs/QmTie", Order: 2, Salt: "cmp"}, sk)
+
+	testCompare(t, "order", newer, older, 1)
+	testCompare(t, "same record", newer, same, 0)
+
+	// Ties are broken the same way whatever the argument order
+	cmp, err := Compare(newer, tie)
+	if err != nil {
+		t.Fatal(err)
+	} else if cmp == 0 {
+		t.Errorf("different records with the same order compare equal")
+	}
+	testCompare(t, "tie", newer, tie, cmp)
+
+	for _, rec := range []*Record{
+		testRecord(t, &Record{CID: testCID, Order: 1, Salt: "other"}, sk),
+		testRecord(t, &Record{CID: testCID, Order: 1, Salt: "cmp"}, other),
+	} {
+		if _, err := Compare(older, rec); err != ErrIncomparable {
+			t.Errorf("got %v, expected ErrIncomparable", err)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	sk := testKey(t)
+	var values [][]byte
+	for _, order := range []uint64{3, 5, 4} {
+		data, err := (&Record{CID: testCID, Order: order, Salt: "sel"}).Encode(sk, CBORCodec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, data)
+	}
+	values = append(values, []byte("garbage"))
+
+	path, err := Path("sel", sk.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, err := Select(path, values)
+	if err != nil {
+		t.Fatal(err)
+	} else if i != 1 {
+		t.Errorf("selected %d, expected 1", i)
+	}
+
+	if _, err := Select(path, values[3:]); err != ErrNoValidRecord {
+		t.Errorf("got %v, expected ErrNoValidRecord", err)
+	}
+}
@@ -0,0 +1,110 @@
+package osr
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	proto "github.com/gogo/protobuf/proto"
+	b58 "github.com/jbenet/go-base58"
+	ic "github.com/libp2p/go-libp2p-crypto"
+	pb "github.com/libp2p/go-libp2p-crypto/pb"
+)
+
+// Describe a decoded record for humans, one "name: value" line per field
+func (r *Record) Describe() []string {
+	var lines []string
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	key, err := r.Key()
+	if err != nil {
+		key = err.Error()
+	}
+	add("path:    %s", key)
+
+	if r.IsMulti() {
+		pks, err := r.GetPublicKeys()
+		if err != nil {
+			add("keys:    %s", err)
+		} else {
+			add("keys:    %d of %d", r.Threshold, len(pks))
+			for _, pk := range pks {
+				add("  - %s %s", keyType(pk), KeyFingerprint(pk))
+			}
+		}
+	} else if pk, err := r.GetPublicKey(); err != nil {
+		add("key:     %s", err)
+	} else {
+		add("key:     %s %s", keyType(pk), KeyFingerprint(pk))
+	}
+
+	if r.Rotation != nil {
+		if root, err := r.GetRootPublicKey(); err == nil {
+			add("rotated: from %s", KeyFingerprint(root))
+		}
+	}
+
+	add("salt:    %s", r.Salt)
+	add("order:   %s", formatOrder(r.Order))
+	add("version: %d", r.Version)
+	if r.NotBefore != 0 {
+		add("nbf:     %s", time.Unix(int64(r.NotBefore), 0).UTC().Format(time.RFC3339))
+	}
+	if r.EOL != 0 {
+		add("eol:     %s", time.Unix(int64(r.EOL), 0).UTC().Format(time.RFC3339))
+	}
+
+	if r.Revoked {
+		add("revoked")
+	} else if r.IsSealed() && len(r.Recipients) > 0 {
+		add("cid:     (sealed for %d recipients)", len(r.Recipients))
+	} else if r.IsSealed() {
+		add("cid:     (sealed with a read key)")
+	} else if r.IsIndex() {
+		add("salts:   %s", strings.Join(r.Salts, " "))
+	} else {
+		add("cid:     %s", r.CID)
+	}
+	if r.Prev != nil {
+		add("prev:    %s", r.PrevObjAddr())
+	}
+	return lines
+}
+
+// Fingerprint of a public key, the base58 multihash of the key
+func KeyFingerprint(pk ic.PubKey) string {
+	h, err := pk.Hash()
+	if err != nil {
+		return err.Error()
+	}
+	return b58.Encode(h)
+}
+
+// Format a record order, with a date if it looks like a Unix timestamp or a
+// clock order
+func formatOrder(order uint64) string {
+	// Between 2001 and 2286
+	if order >= ClockOrder(time.Unix(1e9, 0)) && order < ClockOrder(time.Unix(1e10, 0)) {
+		return fmt.Sprintf("%d (%s)", order, ClockTime(order).UTC().Format(time.RFC3339Nano))
+	} else if order >= 1e9 && order < 1e10 {
+		return fmt.Sprintf("%d (%s)", order, time.Unix(int64(order), 0).UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%d", order)
+}
+
+// Name of the key algorithm
+func keyType(pk ic.PubKey) string {
+	data, err := pk.Bytes()
+	if err != nil {
+		return err.Error()
+	}
+
+	var pbk pb.PublicKey
+	err = proto.Unmarshal(data, &pbk)
+	if err != nil {
+		return err.Error()
+	}
+	return pbk.GetType().String()
+}
@@ -0,0 +1,105 @@
+package osr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"ipobj"
+
+	cid "github.com/ipfs/go-cid"
+	ic "github.com/libp2p/go-libp2p-crypto"
+	mh "github.com/multiformats/go-multihash"
+)
+
+var ErrBrokenHistory error = errors.New("Broken record history")
+
+// Multihash of an encoded record, as referenced by Prev. Witness signatures
+// are left out, see StripWitnesses.
+func Hash(rec []byte) (mh.Multihash, error) {
+	rec, err := StripWitnesses(rec)
+	if err != nil {
+		return nil, err
+	}
+	return mh.Sum(rec, mh.SHA2_256, -1)
+}
+
+// Address of an encoded record, to serve and fetch it as an object. The
+// object is the record without its witnesses, see StripWitnesses.
+func RecordObjAddr(rec []byte) (ipobj.ObjAddr, error) {
+	hash, err := Hash(rec)
+	if err != nil {
+		return nil, err
+	}
+	return ipobj.ObjAddr(cid.NewCidV1(cid.Raw, hash).Bytes()), nil
+}
+
+// Address of the previous record, nil if there is none
+func (r *Record) PrevObjAddr() ipobj.ObjAddr {
+	if r.Prev == nil {
+		return nil
+	}
+	return ipobj.ObjAddr(cid.NewCidV1(cid.Raw, r.Prev).Bytes())
+}
+
+// Walk the history of an encoded record, calling fn for the record and each
+// of its previous versions, most recent first, along with their address.
+// Previous versions are fetched from the network and must match their hash,
+// have the same path and be strictly older than the next version. Validity
+// windows are not checked as past versions may have expired. pk is the public
+// key of compact records and may be nil.
+func History(ctx context.Context, net ipobj.Network, data []byte, pk ic.PubKey, fn func(rec *Record, addr ipobj.ObjAddr) error) error {
+	rec, err := decode(data, pk, false)
+	if err != nil {
+		return err
+	}
+
+	addr, err := RecordObjAddr(data)
+	if err != nil {
+		return err
+	}
+
+	for {
+		err = fn(rec, addr)
+		if err != nil {
+			return err
+		}
+
+		if rec.Prev == nil {
+			return nil
+		}
+
+		addr = rec.PrevObjAddr()
+		reader, err := net.GetObject(ctx, addr)
+		if err != nil {
+			return err
+		}
+
+		data, err = ipobj.ReaderToBytes(reader)
+		if err != nil {
+			return err
+		}
+
+		hash, err := Hash(data)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(hash, rec.Prev) {
+			return ErrBrokenHistory
+		}
+
+		prev, err := decode(data, pk, false)
+		if err != nil {
+			return err
+		}
+
+		cmp, err := Compare(prev, rec)
+		if err != nil {
+			return err
+		} else if cmp >= 0 {
+			return ErrBrokenHistory
+		}
+
+		rec = prev
+	}
+}
@@ -0,0 +1,99 @@
+package osr
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"ipobj"
+
+	mh "github.com/multiformats/go-multihash"
+)
+
+// Salt index convention
+//
+// A key can publish many records under a salt prefix. To let readers
+// discover them, the publisher maintains an index record at the salt
+// "<prefix>/_salts" (or "_salts" for the root of the key) listing the salts
+// under that prefix in its Salts field. Index records are normal records,
+// ordered and updated the same way.
+
+// Salt segment reserved for index records
+const IndexSegment = "_salts"
+
+var ErrNotIndex error = errors.New("Not an index record")
+
+// Salt of the index record for prefix
+func IndexSalt(prefix string) string {
+	if prefix == "" {
+		return IndexSegment
+	}
+	return prefix + "/" + IndexSegment
+}
+
+// Create an index record listing salts under prefix
+func NewIndex(prefix string, salts []string, order uint64) (*Record, error) {
+	err := CheckSalt(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, salt := range salts {
+		err = CheckSalt(salt)
+		if err != nil {
+			return nil, err
+		}
+		if prefix != "" && !strings.HasPrefix(salt, prefix+"/") {
+			return nil, ErrInvalidSalt
+		}
+	}
+
+	return &Record{
+		Order: order,
+		Salt:  IndexSalt(prefix),
+		Salts: salts,
+	}, nil
+}
+
+func (r *Record) IsIndex() bool {
+	return r.Salt == IndexSegment || strings.HasSuffix(r.Salt, "/"+IndexSegment)
+}
+
+// List the salts of an index record
+func (r *Record) ListSalts() ([]string, error) {
+	if !r.IsIndex() {
+		return nil, ErrNotIndex
+	}
+	return r.Salts, nil
+}
+
+// Look up the index record of a key for prefix on the network and return the
+// salts it lists. The most recent index found before ctx is done is used.
+func ListSalts(ctx context.Context, net ipobj.Network, keyHash mh.Multihash, prefix string) ([]string, error) {
+	path, err := PathFromHash(keyHash, IndexSalt(prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	var best *Record
+	for value := range net.GetRecord(ctx, Key(path)) {
+		rec, err := DecodeStrict(value.Content, path)
+		if err != nil {
+			continue
+		}
+
+		if best != nil {
+			cmp, err := Compare(rec, best)
+			if err != nil || cmp <= 0 {
+				continue
+			}
+		}
+		best = rec
+	}
+
+	if best == nil {
+		return nil, ErrNoValidRecord
+	}
+
+	return best.ListSalts()
+}
@@ -0,0 +1,114 @@
+package osr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	proto "github.com/gogo/protobuf/proto"
+	pb "github.com/ipfs/go-ipfs/namesys/pb"
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+// IPNS conversion
+//
+// OSRs and IPNS entries carry the same information: a value, a sequence
+// number and a signing key. An OSR without salt converts to an IPNS entry
+// for the name of its key, with the payload as value, the order as sequence
+// and the end of life as validity. Records without end of life get an IPNS
+// validity of DefaultIPNSLifetime. Sealed, revoked, multi-signature and
+// rotated records have no IPNS equivalent.
+
+// IPNS validity of records without end of life
+const DefaultIPNSLifetime = 24 * time.Hour
+
+var ErrNoIPNS error = errors.New("Record cannot be converted to IPNS")
+
+// Sign the record as an IPNS entry with sk, which must be the record key
+// if the record has one
+func (r *Record) ToIPNS(sk ic.PrivKey) ([]byte, error) {
+	if r.Salt != "" || r.Revoked || r.IsSealed() || r.IsMulti() || r.Rotation != nil || r.IsBatch() {
+		return nil, ErrNoIPNS
+	}
+
+	if r.PublicKey != "" {
+		pk, err := sk.GetPublic().Bytes()
+		if err != nil {
+			return nil, err
+		}
+		if base64.RawStdEncoding.EncodeToString(pk) != r.PublicKey {
+			return nil, ErrKeyMismatch
+		}
+	}
+
+	payload, err := r.GetPayload()
+	if err != nil {
+		return nil, err
+	}
+
+	eol := time.Now().Add(DefaultIPNSLifetime)
+	if r.EOL != 0 {
+		eol = time.Unix(int64(r.EOL), 0)
+	}
+
+	entry := &pb.IpnsEntry{
+		Value:        []byte(payload.String()),
+		ValidityType: pb.IpnsEntry_EOL.Enum(),
+		Validity:     []byte(eol.UTC().Format(time.RFC3339Nano)),
+		Sequence:     proto.Uint64(r.Order),
+	}
+	entry.Signature, err = sk.Sign(ipnsDataForSig(entry))
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(entry)
+}
+
+// Parse and verify an IPNS entry signed by pk into an unsigned record, to
+// be signed with Encode
+func FromIPNS(data []byte, pk ic.PubKey) (*Record, error) {
+	var entry pb.IpnsEntry
+	err := proto.Unmarshal(data, &entry)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := pk.Verify(ipnsDataForSig(&entry), entry.GetSignature())
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrInvalidSignature
+	}
+
+	if entry.GetValidityType() != pb.IpnsEntry_EOL {
+		return nil, ErrNoIPNS
+	}
+	eol, err := time.Parse(time.RFC3339Nano, string(entry.GetValidity()))
+	if err != nil {
+		return nil, err
+	}
+
+	pkd, err := pk.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Record{
+		CID:       string(entry.GetValue()),
+		Order:     entry.GetSequence(),
+		PublicKey: base64.RawStdEncoding.EncodeToString(pkd),
+		EOL:       uint64(eol.Unix()),
+	}, nil
+}
+
+// Bytes signed in an IPNS entry, as go-ipfs namesys does
+func ipnsDataForSig(e *pb.IpnsEntry) []byte {
+	return bytes.Join([][]byte{
+		e.Value,
+		e.Validity,
+		[]byte(fmt.Sprint(e.GetValidityType())),
+	}, []byte{})
+}
@@ -0,0 +1,86 @@
+package osr
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestIPNS(t *testing.T) {
+	sk, other := testKey(t), testKey(t)
+	otherPk, err := other.GetPublic().Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eol := uint64(time.Now().Add(time.Hour).Unix())
+	path, err := Path("", sk.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed := &Record{CID: testCID, Order: 1}
+	var readKey [ReadKeySize]byte
+	err = sealed.SealWithKey(&readKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		rec  *Record
+		err  error
+	}{
+		{"record", &Record{CID: testCID, Order: 5, EOL: eol}, nil},
+		{"no end of life", &Record{CID: testCID, Order: 6}, nil},
+		{"record path", &Record{CID: "/iprs/osr/QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn/web", Order: 7}, nil},
+		{"salt", &Record{CID: testCID, Order: 1, Salt: "web"}, ErrNoIPNS},
+		{"tombstone", NewTombstone("", 1), ErrNoIPNS},
+		{"sealed", sealed, ErrNoIPNS},
+		{"other key", &Record{CID: testCID, Order: 1, PublicKey: base64.RawStdEncoding.EncodeToString(otherPk)}, ErrKeyMismatch},
+		{"invalid payload", &Record{CID: "hello", Order: 1}, ErrInvalidPayload},
+	}
+
+	for _, c := range cases {
+		entry, err := c.rec.ToIPNS(sk)
+		if err != c.err {
+			t.Errorf("%s: got %v, expected %v", c.name, err, c.err)
+			continue
+		} else if err != nil {
+			continue
+		}
+
+		if _, err := FromIPNS(entry, other.GetPublic()); err != ErrInvalidSignature {
+			t.Errorf("%s: other key: got %v, expected ErrInvalidSignature", c.name, err)
+		}
+
+		rec, err := FromIPNS(entry, sk.GetPublic())
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		payload, err := c.rec.GetPayload()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.CID != payload.String() || rec.Order != c.rec.Order {
+			t.Errorf("%s: converted back as %+v", c.name, rec)
+		}
+		if c.rec.EOL != 0 && rec.EOL != c.rec.EOL {
+			t.Errorf("%s: end of life %d, expected %d", c.name, rec.EOL, c.rec.EOL)
+		} else if c.rec.EOL == 0 && rec.EOL < uint64(time.Now().Add(DefaultIPNSLifetime-time.Minute).Unix()) {
+			t.Errorf("%s: end of life %d, expected the default lifetime", c.name, rec.EOL)
+		}
+
+		// The converted record is signed by the same key
+		data, err := rec.Encode(sk, CBORCodec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec, err := Decode(data)
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+		} else if p, err := dec.Path(); err != nil || p != path {
+			t.Errorf("%s: converted record at %s (%v), expected %s", c.name, p, err, path)
+		}
+	}
+}
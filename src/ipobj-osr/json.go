@@ -0,0 +1,140 @@
+package osr
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/multiformats/go-multicodec"
+)
+
+var HeaderJSON = multicodec.Header([]byte("/json"))
+
+// JSON codec, the original OSR format. Binary fields are encoded using
+// unpadded base64.
+var JSONCodec Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+type signedRecord struct {
+	Record     json.RawMessage `json:"rec"`
+	Signature  string          `json:"sig,omitempty"`
+	Signatures []string        `json:"sigs,omitempty"`
+
+	Batch string   `json:"batch,omitempty"`
+	Proof []string `json:"proof,omitempty"`
+	Index uint64   `json:"idx,omitempty"`
+
+	Witnesses []jsonWitness `json:"wits,omitempty"`
+}
+
+type jsonWitness struct {
+	PublicKey string `json:"pkey"`
+	Time      uint64 `json:"t"`
+	Signature string `json:"sig"`
+}
+
+func (jsonCodec) Header() []byte {
+	return HeaderJSON
+}
+
+func (jsonCodec) EncodeRecord(r *Record) ([]byte, error) {
+	return canonicalJSON(r)
+}
+
+func (jsonCodec) DecodeRecord(data []byte, r *Record) error {
+	return json.Unmarshal(data, r)
+}
+
+func (jsonCodec) EncodeSigned(sr *SignedRecord) ([]byte, error) {
+	var sigs []string
+	for _, sig := range sr.Signatures {
+		sigs = append(sigs, base64.RawStdEncoding.EncodeToString(sig))
+	}
+	var proof []string
+	for _, hash := range sr.Proof {
+		proof = append(proof, base64.RawStdEncoding.EncodeToString(hash))
+	}
+	var wits []jsonWitness
+	for _, w := range sr.Witnesses {
+		wits = append(wits, jsonWitness{
+			PublicKey: base64.RawStdEncoding.EncodeToString(w.PublicKey),
+			Time:      w.Time,
+			Signature: base64.RawStdEncoding.EncodeToString(w.Signature),
+		})
+	}
+	return json.Marshal(&signedRecord{
+		Record:     sr.Record,
+		Signature:  base64.RawStdEncoding.EncodeToString(sr.Signature),
+		Signatures: sigs,
+		Batch:      base64.RawStdEncoding.EncodeToString(sr.Batch),
+		Proof:      proof,
+		Index:      sr.Index,
+		Witnesses:  wits,
+	})
+}
+
+func (jsonCodec) DecodeSigned(data []byte, sr *SignedRecord) error {
+	var jsr signedRecord
+	err := json.Unmarshal(data, &jsr)
+	if err != nil {
+		return err
+	}
+
+	sig, err := base64.RawStdEncoding.DecodeString(jsr.Signature)
+	if err != nil {
+		return err
+	}
+
+	var sigs [][]byte
+	for _, s := range jsr.Signatures {
+		sig, err := base64.RawStdEncoding.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		sigs = append(sigs, sig)
+	}
+
+	// Leave the batch nil when absent, decoding "" gives an empty slice
+	var batch []byte
+	if jsr.Batch != "" {
+		batch, err = base64.RawStdEncoding.DecodeString(jsr.Batch)
+		if err != nil {
+			return err
+		}
+	}
+
+	var proof [][]byte
+	for _, p := range jsr.Proof {
+		hash, err := base64.RawStdEncoding.DecodeString(p)
+		if err != nil {
+			return err
+		}
+		proof = append(proof, hash)
+	}
+
+	var wits []Witness
+	for _, w := range jsr.Witnesses {
+		pk, err := base64.RawStdEncoding.DecodeString(w.PublicKey)
+		if err != nil {
+			return err
+		}
+		sig, err := base64.RawStdEncoding.DecodeString(w.Signature)
+		if err != nil {
+			return err
+		}
+		wits = append(wits, Witness{
+			PublicKey: pk,
+			Time:      w.Time,
+			Signature: sig,
+		})
+	}
+
+	sr.Record = jsr.Record
+	sr.Signature = sig
+	sr.Signatures = sigs
+	sr.Batch = batch
+	sr.Proof = proof
+	sr.Index = jsr.Index
+	sr.Witnesses = wits
+	return nil
+}
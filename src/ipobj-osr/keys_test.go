@@ -0,0 +1,153 @@
+package osr
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+// Fixed keys of every supported algorithm, in testdata/<name>.key as written
+// by keygen. testdata/<name>.osr is the record testVector signed by the key.
+func testKeys(t *testing.T) map[string]ic.PrivKey {
+	keys := map[string]ic.PrivKey{}
+	for _, name := range []string{"ed25519", "rsa", "secp256k1", "ecdsa"} {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", name+".key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sk, err := ic.UnmarshalPrivateKey(data)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		keys[name] = sk
+	}
+	return keys
+}
+
+// Record signed in the test vectors, with the CBOR codec
+func testVector() *Record {
+	return &Record{CID: testCID, Order: 1, Salt: "vectors"}
+}
+
+func TestKeyVectors(t *testing.T) {
+	for name, sk := range testKeys(t) {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", name+".osr"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dec, err := Decode(data)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		pk, err := dec.GetPublicKey()
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		} else if !pk.Equals(sk.GetPublic()) {
+			t.Errorf("%s: signed by another key", name)
+		} else if dec.CID != testCID || dec.Salt != "vectors" || dec.Order != 1 {
+			t.Errorf("%s: decoded as %+v", name, dec)
+		}
+
+		// ECDSA signatures are randomized, the other algorithms must sign
+		// the record byte for byte
+		if name == "ecdsa" {
+			continue
+		}
+		enc, err := testVector().Encode(sk, CBORCodec)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		} else if !bytes.Equal(enc, data) {
+			t.Errorf("%s: encoded as %x, expected %x", name, enc, data)
+		}
+	}
+}
+
+func TestKeyTypes(t *testing.T) {
+	for kname, sk := range testKeys(t) {
+		for cname, codec := range testCodecs {
+			name := kname + "/" + cname
+
+			rec := &Record{CID: testCID, Order: 1, Salt: "keys"}
+			data, err := rec.Encode(sk, codec)
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+
+			dec, err := Decode(data)
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			pk, err := dec.GetPublicKey()
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			} else if !pk.Equals(sk.GetPublic()) {
+				t.Errorf("%s: decoded another public key", name)
+			}
+
+			path, err := dec.Path()
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			expected, err := Path(rec.Salt, sk.GetPublic())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path != expected {
+				t.Errorf("%s: path %s, expected %s", name, path, expected)
+			}
+			if err := Validate(path, data); err != nil {
+				t.Errorf("%s: validate: %s", name, err)
+			}
+
+			// Altering the signature must fail verification
+			_, sr, _, err := decodeEnvelope(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sr.Signature[len(sr.Signature)/2] ^= 1
+			forged, err := encodeEnvelope(codec, sr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Decode(forged); err == nil {
+				t.Errorf("%s: altered signature verified", name)
+			}
+		}
+	}
+}
+
+func TestCompactKeyTypes(t *testing.T) {
+	for kname, sk := range testKeys(t) {
+		pk := sk.GetPublic()
+		for cname, codec := range testCodecs {
+			name := kname + "/" + cname
+
+			rec := &Record{CID: testCID, Order: 1, Salt: "compact"}
+			data, err := rec.EncodeCompact(sk, codec)
+			if !CanInline(pk) {
+				if err != ErrCannotInline {
+					t.Errorf("%s: got %v, expected ErrCannotInline", name, err)
+				}
+				continue
+			} else if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+
+			path, err := CompactPath(rec.Salt, pk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Decode(data); err != ErrMissingKey {
+				t.Errorf("%s: decoded without key: %v", name, err)
+			}
+			if err := Validate(path, data); err != nil {
+				t.Errorf("%s: validate: %s", name, err)
+			}
+		}
+	}
+}
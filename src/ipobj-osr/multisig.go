@@ -0,0 +1,190 @@
+package osr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+	mh "github.com/multiformats/go-multihash"
+)
+
+var ErrNotMulti error = errors.New("Not a multi-signature record")
+var ErrNotSigner error = errors.New("Key is not a signer of the record")
+var ErrInvalidThreshold error = errors.New("Invalid signature threshold")
+var ErrDuplicateKey error = errors.New("Duplicate public key")
+var ErrNotEnoughSignatures error = errors.New("Not enough valid signatures")
+
+func (r *Record) IsMulti() bool {
+	return len(r.PublicKeys) > 0
+}
+
+// Make the record a multi-signature record for the given key set
+func (r *Record) SetPublicKeys(pks []ic.PubKey, threshold uint32) error {
+	var keys []string
+	for _, pk := range pks {
+		data, err := pk.Bytes()
+		if err != nil {
+			return err
+		}
+		keys = append(keys, base64.RawStdEncoding.EncodeToString(data))
+	}
+
+	r.PublicKey = ""
+	r.PublicKeys = keys
+	r.Threshold = threshold
+	return nil
+}
+
+func (r *Record) GetPublicKeys() ([]ic.PubKey, error) {
+	var pks []ic.PubKey
+	for _, key := range r.PublicKeys {
+		pkd, err := base64.RawStdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, err
+		}
+		pk, err := ic.UnmarshalPublicKey(pkd)
+		if err != nil {
+			return nil, err
+		}
+		pks = append(pks, pk)
+	}
+	return pks, nil
+}
+
+// Path of a multi-signature record. The key fingerprint is the hash of the
+// threshold and the sorted key set, so the order of the keys does not matter.
+func MultiPath(salt string, pks []ic.PubKey, threshold uint32) (string, error) {
+	var keys [][]byte
+	for _, pk := range pks {
+		data, err := pk.Bytes()
+		if err != nil {
+			return "", err
+		}
+		keys = append(keys, data)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+
+	var buf []byte
+	var varint [binary.MaxVarintLen64]byte
+	buf = append(buf, varint[:binary.PutUvarint(varint[:], uint64(threshold))]...)
+	for _, key := range keys {
+		buf = append(buf, varint[:binary.PutUvarint(varint[:], uint64(len(key)))]...)
+		buf = append(buf, key...)
+	}
+
+	hash, err := mh.Sum(buf, mh.SHA2_256, -1)
+	if err != nil {
+		return "", err
+	}
+
+	return PathFromHash(hash, salt)
+}
+
+func (r *Record) verifyMulti(sr *SignedRecord) error {
+	if r.Threshold == 0 || int(r.Threshold) > len(r.PublicKeys) {
+		return ErrInvalidThreshold
+	}
+
+	keys := map[string]bool{}
+	for _, key := range r.PublicKeys {
+		if keys[key] {
+			return ErrDuplicateKey
+		}
+		keys[key] = true
+	}
+
+	pks, err := r.GetPublicKeys()
+	if err != nil {
+		return err
+	}
+
+	if len(sr.Signatures) > len(pks) {
+		return ErrInvalidSignature
+	}
+
+	var valid uint32
+	for i, sig := range sr.Signatures {
+		if len(sig) == 0 {
+			continue
+		}
+		ok, err := pks[i].Verify(sr.Record, sig)
+		if err != nil {
+			return err
+		} else if !ok {
+			return ErrInvalidSignature
+		}
+		valid++
+	}
+
+	if valid < r.Threshold {
+		return ErrNotEnoughSignatures
+	}
+
+	return nil
+}
+
+// Encode a multi-signature record without any signature. Signers then add
+// their signature using Sign.
+func (r *Record) EncodeMulti(codec Codec) ([]byte, error) {
+	if !r.IsMulti() {
+		return nil, ErrNotMulti
+	}
+
+	var ur Record = *r
+	ur.Version = RecordVersion
+
+	urd, err := codec.EncodeRecord(&ur)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeEnvelope(codec, &SignedRecord{
+		Record:     urd,
+		Signatures: make([][]byte, len(r.PublicKeys)),
+	})
+}
+
+// Add the signature of sk to an encoded multi-signature record
+func Sign(rec []byte, sk ic.PrivKey) ([]byte, error) {
+	codec, sr, ur, err := decodeEnvelope(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ur.IsMulti() {
+		return nil, ErrNotMulti
+	}
+
+	pk, err := sk.GetPublic().Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	key := base64.RawStdEncoding.EncodeToString(pk)
+	for i, k := range ur.PublicKeys {
+		if k != key {
+			continue
+		}
+
+		sig, err := sk.Sign(sr.Record)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(sr.Signatures) != len(ur.PublicKeys) {
+			sigs := make([][]byte, len(ur.PublicKeys))
+			copy(sigs, sr.Signatures)
+			sr.Signatures = sigs
+		}
+		sr.Signatures[i] = sig
+
+		return encodeEnvelope(codec, sr)
+	}
+
+	return nil, ErrNotSigner
+}
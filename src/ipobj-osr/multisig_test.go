@@ -0,0 +1,67 @@
+package osr
+
+import (
+	"testing"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+// Encode a multi-signature record for pks signed by signers
+func testMulti(t *testing.T, pks []ic.PubKey, threshold uint32, signers ...ic.PrivKey) []byte {
+	rec := &Record{CID: testCID, Order: 1, Salt: "multi"}
+	err := rec.SetPublicKeys(pks, threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := rec.EncodeMulti(CBORCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sk := range signers {
+		data, err = Sign(data, sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return data
+}
+
+func TestMultiSig(t *testing.T) {
+	k0, k1, k2, other := testKey(t), testKey(t), testKey(t), testKey(t)
+	pks := []ic.PubKey{k0.GetPublic(), k1.GetPublic(), k2.GetPublic()}
+
+	first, err := Decode(testMulti(t, pks, 2, k0, k1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Decode(testMulti(t, pks, 2, k2, k0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The path does not depend on the key order
+	path, err := MultiPath("multi", []ic.PubKey{pks[2], pks[0], pks[1]}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := first.Path(); err != nil || p != path {
+		t.Errorf("path %s (%v), expected %s", p, err, path)
+	}
+
+	// Signature sets do not change the record
+	testCompare(t, "other signers", first, second, 0)
+
+	if _, err := Decode(testMulti(t, pks, 2, k1)); err != ErrNotEnoughSignatures {
+		t.Errorf("one signature: got %v, expected ErrNotEnoughSignatures", err)
+	}
+	if _, err := Sign(testMulti(t, pks, 2), other); err != ErrNotSigner {
+		t.Errorf("other signer: got %v, expected ErrNotSigner", err)
+	}
+	if _, err := Decode(testMulti(t, pks, 4, k0, k1, k2)); err != ErrInvalidThreshold {
+		t.Errorf("threshold above the keys: got %v, expected ErrInvalidThreshold", err)
+	}
+	dup := []ic.PubKey{pks[0], pks[0], pks[1]}
+	if _, err := Decode(testMulti(t, dup, 2, k0, k1)); err != ErrDuplicateKey {
+		t.Errorf("duplicate key: got %v, expected ErrDuplicateKey", err)
+	}
+}
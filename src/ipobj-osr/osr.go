@@ -3,10 +3,11 @@ package osr
 import (
 	"bytes"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
+	"time"
+
+	"ipobj"
 
-	b58 "github.com/jbenet/go-base58"
 	ic "github.com/libp2p/go-libp2p-crypto"
 	"github.com/multiformats/go-multicodec"
 )
@@ -16,62 +17,229 @@ type Record struct {
 	Order     uint64 `json:"ord"`
 	PublicKey string `json:"pkey"`
 	Salt      string `json:"salt"`
-}
 
-type signedRecord struct {
-	Record    json.RawMessage `json:"rec"`
-	Signature string          `json:"sig"`
+	// Optional validity window, in seconds since the Unix epoch. Zero means
+	// no bound.
+	NotBefore uint64 `json:"nbf,omitempty"`
+	EOL       uint64 `json:"eol,omitempty"`
+
+	// Key rotation. A rotation record designates the Successor public key
+	// (base64) for the same salt. Records signed by a successor key embed
+	// the encoded rotation record in Rotation.
+	Successor string `json:"next,omitempty"`
+	Rotation  []byte `json:"rot,omitempty"`
+
+	// Multi-signature records are identified by a set of public keys
+	// (base64) instead of PublicKey, and need at least Threshold valid
+	// signatures.
+	PublicKeys []string `json:"pkeys,omitempty"`
+	Threshold  uint32   `json:"thr,omitempty"`
+
+	// Tombstone: the record is revoked for good and carries no CID
+	Revoked bool `json:"revoked,omitempty"`
+
+	// Multihash of the previous encoded record, if any
+	Prev []byte `json:"prev,omitempty"`
+
+	// Sealed payload replacing CID, and the content key wrapped for each
+	// recipient, see SealTo
+	SealedCID  []byte   `json:"enc,omitempty"`
+	Recipients [][]byte `json:"rcpt,omitempty"`
+
+	// Salts listed by an index record, see IndexSalt
+	Salts []string `json:"salts,omitempty"`
+
+	// Batch root records sign the Merkle root of a batch of records and
+	// its number of records instead of a payload, see EncodeBatch
+	Batch     []byte `json:"batch,omitempty"`
+	BatchSize uint64 `json:"bsize,omitempty"`
+
+	// Record format version, see RecordVersion. Version 0 records predate
+	// canonical serialization.
+	Version uint32 `json:"v,omitempty"`
+
+	// Bytes hashed to break ties in Compare, set by Decode: the signature,
+	// or the signed record for multi-signature records as their set of
+	// signatures can vary.
+	tieBreak []byte
+
+	// Length of the rotation chain and decoded rotation record, set by
+	// Decode
+	depth    int
+	rotation *Record
+
+	// Set by Decode when the public key was not part of the record and
+	// is inlined in the record path instead
+	compact bool
 }
 
 var HeaderOSR = multicodec.Header([]byte("/ipfs/record/mildred-ordered-signed-record"))
-var HeaderJSON = multicodec.Header([]byte("/json"))
 
 var ErrInvalidSignature error = errors.New("Invalid Signature")
+var ErrExpired error = ipobj.ErrExpired
+var ErrNotValidYet error = errors.New("Record not valid yet")
 
 func Decode(rec []byte) (*Record, error) {
-	if bytes.HasPrefix(rec, HeaderOSR) {
-		rec = rec[len(HeaderOSR):]
-	}
-	if bytes.HasPrefix(rec, HeaderJSON) {
-		rec = rec[len(HeaderJSON):]
-	}
+	return decode(rec, nil, true)
+}
 
-	var sr signedRecord
-	var ur Record
+// Decode a record using pk as public key. This is required for compact
+// records that do not include their public key. For other records, the
+// included public key must match pk.
+func DecodeWithKey(rec []byte, pk ic.PubKey) (*Record, error) {
+	return decode(rec, pk, true)
+}
 
-	err := json.Unmarshal(rec, &sr)
+// Decode and verify a record, checking its validity window only if validity
+// is true. pk is the public key of compact records and may be nil.
+func decode(rec []byte, pk ic.PubKey, validity bool) (*Record, error) {
+	codec, sr, ur, err := decodeEnvelope(rec)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(sr.Record, &ur)
+	if len(sr.Batch) > 0 {
+		return decodeBatched(codec, sr, ur, pk, validity)
+	} else if ur.IsBatch() {
+		// Batch roots are only valid as part of a batched record
+		return nil, ErrInvalidBatch
+	}
+
+	err = ur.check(codec, sr, pk, validity)
 	if err != nil {
 		return nil, err
 	}
+	return ur, nil
+}
 
-	pkd, err := base64.RawStdEncoding.DecodeString(ur.PublicKey)
+// Check a decoded record against its signed envelope, see decode
+func (ur *Record) check(codec Codec, sr *SignedRecord, pk ic.PubKey, validity bool) error {
+	err := checkCanonical(codec, sr, ur)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	pk, err := ic.UnmarshalPublicKey(pkd)
+	if pk != nil && !ur.IsMulti() {
+		pkdata, err := pk.Bytes()
+		if err != nil {
+			return err
+		}
+		pkey := base64.RawStdEncoding.EncodeToString(pkdata)
+		if ur.PublicKey == "" {
+			ur.PublicKey = pkey
+			ur.compact = true
+		} else if ur.PublicKey != pkey {
+			return ErrKeyMismatch
+		}
+	} else if ur.PublicKey == "" && !ur.IsMulti() {
+		return ErrMissingKey
+	}
+
+	if ur.IsMulti() {
+		err = ur.verifyMulti(sr)
+		ur.tieBreak = sr.Record
+	} else {
+		err = ur.verify(sr)
+		ur.tieBreak = sr.Signature
+	}
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	if validity {
+		err = ur.Valid(time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	if ur.Rotation != nil {
+		rot, err := decode(ur.Rotation, nil, validity)
+		if err != nil {
+			return err
+		}
+		if rot.Successor == "" || rot.Successor != ur.PublicKey || rot.Salt != ur.Salt {
+			return ErrInvalidRotation
+		}
+		ur.depth = rot.depth + 1
+		ur.rotation = rot
+	}
+
+	return nil
+}
+
+// Check the version of a record and that it was signed in canonical form
+func checkCanonical(codec Codec, sr *SignedRecord, ur *Record) error {
+	if ur.Version > RecordVersion {
+		return ErrUnsupportedVersion
+	} else if ur.Version > 0 {
+		canonical, err := codec.EncodeRecord(ur)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(canonical, sr.Record) {
+			return ErrNotCanonical
+		}
+	}
+	return nil
+}
+
+// Decode headers, signed envelope and record without any verification
+func decodeEnvelope(rec []byte) (Codec, *SignedRecord, *Record, error) {
+	if bytes.HasPrefix(rec, HeaderOSR) {
+		rec = rec[len(HeaderOSR):]
+	}
+
+	// Records without codec header are JSON
+	codec := codecFor(rec)
+	if codec != nil {
+		rec = rec[len(codec.Header()):]
+	} else {
+		codec = JSONCodec
 	}
 
-	sig, err := base64.RawStdEncoding.DecodeString(sr.Signature)
+	var sr SignedRecord
+	var ur Record
+
+	err := codec.DecodeSigned(rec, &sr)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
-	ok, err := pk.Verify(sr.Record, sig)
+	err = codec.DecodeRecord(sr.Record, &ur)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
+	}
+
+	return codec, &sr, &ur, nil
+}
+
+func (r *Record) verify(sr *SignedRecord) error {
+	pk, err := r.GetPublicKey()
+	if err != nil {
+		return err
+	}
+
+	ok, err := pk.Verify(sr.Record, sr.Signature)
+	if err != nil {
+		return err
 	} else if !ok {
-		return nil, ErrInvalidSignature
+		return ErrInvalidSignature
 	}
 
-	return &ur, nil
+	return nil
+}
+
+// Check the record validity window against t
+func (r *Record) Valid(t time.Time) error {
+	now := t.Unix()
+	if r.NotBefore != 0 && now < int64(r.NotBefore) {
+		return ErrNotValidYet
+	}
+	if r.EOL != 0 && now >= int64(r.EOL) {
+		return ErrExpired
+	}
+	return nil
 }
 
 func Path(salt string, pk ic.PubKey) (string, error) {
@@ -79,14 +247,25 @@ func Path(salt string, pk ic.PubKey) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if salt != "" {
-		salt = "/" + salt
-	}
-	return "/osr/" + b58.Encode(data) + salt, nil
+	return PathFromHash(data, salt)
 }
 
 func (r *Record) Path() (string, error) {
-	pk, err := r.GetPublicKey()
+	if r.compact {
+		pk, err := r.GetPublicKey()
+		if err != nil {
+			return "", err
+		}
+		return CompactPath(r.Salt, pk)
+	} else if r.IsMulti() {
+		pks, err := r.GetPublicKeys()
+		if err != nil {
+			return "", err
+		}
+		return MultiPath(r.Salt, pks, r.Threshold)
+	}
+
+	pk, err := r.GetRootPublicKey()
 	if err != nil {
 		return "", err
 	}
@@ -94,6 +273,22 @@ func (r *Record) Path() (string, error) {
 	return Path(r.Salt, pk)
 }
 
+// Get the public key the record path is derived from. It is the record key
+// unless the key was rotated, in which case this is the first key of the
+// rotation chain.
+func (r *Record) GetRootPublicKey() (ic.PubKey, error) {
+	if r.Rotation == nil {
+		return r.GetPublicKey()
+	}
+
+	rot, err := Decode(r.Rotation)
+	if err != nil {
+		return nil, err
+	}
+
+	return rot.GetRootPublicKey()
+}
+
 func (r *Record) GetPublicKey() (ic.PubKey, error) {
 	pkd, err := base64.RawStdEncoding.DecodeString(r.PublicKey)
 	if err != nil {
@@ -103,7 +298,7 @@ func (r *Record) GetPublicKey() (ic.PubKey, error) {
 	return ic.UnmarshalPublicKey(pkd)
 }
 
-func (r *Record) Encode(sk ic.PrivKey) ([]byte, error) {
+func (r *Record) Encode(sk ic.PrivKey, codec Codec) ([]byte, error) {
 	pk, err := sk.GetPublic().Bytes()
 	if err != nil {
 		return nil, err
@@ -111,8 +306,14 @@ func (r *Record) Encode(sk ic.PrivKey) ([]byte, error) {
 
 	var ur Record = *r
 	ur.PublicKey = base64.RawStdEncoding.EncodeToString(pk)
+	return ur.sign(sk, codec)
+}
+
+// Sign the record as is with sk and encode it with its envelope
+func (r *Record) sign(sk ic.PrivKey, codec Codec) ([]byte, error) {
+	r.Version = RecordVersion
 
-	urd, err := json.Marshal(ur)
+	urd, err := codec.EncodeRecord(r)
 	if err != nil {
 		return nil, err
 	}
@@ -122,15 +323,21 @@ func (r *Record) Encode(sk ic.PrivKey) ([]byte, error) {
 		return nil, err
 	}
 
-	sr := signedRecord{
+	return encodeEnvelope(codec, &SignedRecord{
 		Record:    urd,
-		Signature: base64.RawStdEncoding.EncodeToString(sig),
-	}
+		Signature: sig,
+	})
+}
 
-	srd, err := json.Marshal(&sr)
+// Encode signed envelope with its headers
+func encodeEnvelope(codec Codec, sr *SignedRecord) ([]byte, error) {
+	srd, err := codec.EncodeSigned(sr)
 	if err != nil {
 		return nil, err
 	}
 
-	return append(append(HeaderOSR, HeaderJSON...), srd...), nil
+	var res []byte
+	res = append(res, HeaderOSR...)
+	res = append(res, codec.Header()...)
+	return append(res, srd...), nil
 }
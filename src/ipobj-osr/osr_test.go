@@ -0,0 +1,124 @@
+package osr
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+var testCodecs = map[string]Codec{
+	"json":     JSONCodec,
+	"cbor":     CBORCodec,
+	"protobuf": ProtobufCodec,
+}
+
+const testCID = "/ipfs/QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn"
+
+func testKey(t *testing.T) ic.PrivKey {
+	sk, _, err := ic.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sk
+}
+
+func TestEncodeDecode(t *testing.T) {
+	sk := testKey(t)
+	for name, codec := range testCodecs {
+		rec := &Record{CID: testCID, Order: 3, Salt: "web"}
+		data, err := rec.Encode(sk, codec)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		dec, err := Decode(data)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if dec.CID != rec.CID || dec.Order != rec.Order || dec.Salt != rec.Salt {
+			t.Errorf("%s: decoded %+v", name, dec)
+		}
+	}
+}
+
+func TestSignature(t *testing.T) {
+	sk, other := testKey(t), testKey(t)
+	for name, codec := range testCodecs {
+		data, err := (&Record{CID: testCID, Order: 1, Salt: "sig"}).Encode(sk, codec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := DecodeWithKey(data, sk.GetPublic()); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+		if _, err := DecodeWithKey(data, other.GetPublic()); err != ErrKeyMismatch {
+			t.Errorf("%s: other key: got %v, expected ErrKeyMismatch", name, err)
+		}
+
+		// Signed by another key than the record key
+		_, sr, ur, err := decodeEnvelope(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sr.Signature, err = other.Sign(sr.Record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		forged, err := encodeEnvelope(codec, sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Decode(forged); err != ErrInvalidSignature {
+			t.Errorf("%s: other signer: got %v, expected ErrInvalidSignature", name, err)
+		}
+
+		// Record changed after signing
+		ur.Order = 2
+		sr.Record, err = codec.EncodeRecord(ur)
+		if err != nil {
+			t.Fatal(err)
+		}
+		forged, err = encodeEnvelope(codec, sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Decode(forged); err != ErrInvalidSignature {
+			t.Errorf("%s: changed record: got %v, expected ErrInvalidSignature", name, err)
+		}
+	}
+}
+
+func TestValidity(t *testing.T) {
+	sk := testKey(t)
+	now := time.Now()
+
+	cases := []struct {
+		name     string
+		nbf, eol time.Time
+		expected error
+	}{
+		{"valid", now.Add(-time.Hour), now.Add(time.Hour), nil},
+		{"expired", now.Add(-time.Hour), now.Add(-time.Minute), ErrExpired},
+		{"not valid yet", now.Add(time.Hour), now.Add(2 * time.Hour), ErrNotValidYet},
+	}
+
+	for _, c := range cases {
+		rec := &Record{
+			CID:       testCID,
+			Order:     1,
+			Salt:      "valid",
+			NotBefore: uint64(c.nbf.Unix()),
+			EOL:       uint64(c.eol.Unix()),
+		}
+		data, err := rec.Encode(sk, JSONCodec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Decode(data); err != c.expected {
+			t.Errorf("%s: got %v, expected %v", c.name, err, c.expected)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package osr
+
+import (
+	"errors"
+	"strings"
+
+	b58 "github.com/jbenet/go-base58"
+	mh "github.com/multiformats/go-multihash"
+)
+
+var ErrInvalidPath error = errors.New("Invalid OSR path")
+var ErrInvalidSalt error = errors.New("Invalid salt")
+
+// Check a salt. Salts can have multiple segments separated by slashes, such
+// as "projects/web/prod", to organize the records of a key as a namespace.
+// Segments cannot be empty, "." or "..".
+func CheckSalt(salt string) error {
+	if salt == "" {
+		return nil
+	}
+	for _, segment := range strings.Split(salt, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidSalt
+		}
+	}
+	return nil
+}
+
+// Path of a record given its key hash and salt
+func PathFromHash(hash []byte, salt string) (string, error) {
+	err := CheckSalt(salt)
+	if err != nil {
+		return "", err
+	}
+	if salt != "" {
+		salt = "/" + salt
+	}
+	return "/osr/" + b58.Encode(hash) + salt, nil
+}
+
+// Parse a record path of the form /osr/<key hash>/<salt>, with or without
+// the /iprs prefix. Returns the key hash and the salt.
+func ParsePath(path string) (mh.Multihash, string, error) {
+	path = strings.TrimPrefix(path, "/"+Namespace)
+	if !strings.HasPrefix(path, "/osr/") {
+		return nil, "", ErrInvalidPath
+	}
+
+	parts := strings.SplitN(path[len("/osr/"):], "/", 2)
+	hash, err := mh.Cast(b58.Decode(parts[0]))
+	if err != nil {
+		return nil, "", ErrInvalidPath
+	}
+
+	var salt string
+	if len(parts) > 1 {
+		salt = parts[1]
+		if salt == "" {
+			return nil, "", ErrInvalidSalt
+		}
+	}
+
+	err = CheckSalt(salt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return hash, salt, nil
+}
+
+// DHT key for a record path
+func Key(path string) string {
+	return "/" + Namespace + path
+}
+
+// DHT key of the record
+func (r *Record) Key() (string, error) {
+	path, err := r.Path()
+	if err != nil {
+		return "", err
+	}
+	return Key(path), nil
+}
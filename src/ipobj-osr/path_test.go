@@ -0,0 +1,144 @@
+package osr
+
+import (
+	"bytes"
+	"testing"
+
+	b58 "github.com/jbenet/go-base58"
+)
+
+func TestCheckSalt(t *testing.T) {
+	cases := map[string]error{
+		"":                  nil,
+		"web":               nil,
+		"projects/web/prod": nil,
+		"_salts":            nil,
+		"a.b/..c":           nil,
+		"/web":              ErrInvalidSalt,
+		"web/":              ErrInvalidSalt,
+		"a//b":              ErrInvalidSalt,
+		"a/./b":             ErrInvalidSalt,
+		"a/../b":            ErrInvalidSalt,
+		".":                 ErrInvalidSalt,
+		"..":                ErrInvalidSalt,
+	}
+	for salt, expected := range cases {
+		if err := CheckSalt(salt); err != expected {
+			t.Errorf("%q: got %v, expected %v", salt, err, expected)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	sk := testKey(t)
+	hash, err := sk.GetPublic().Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := b58.Encode(hash)
+
+	cases := []struct {
+		path string
+		salt string
+		err  error
+	}{
+		{"/osr/" + h, "", nil},
+		{"/osr/" + h + "/web", "web", nil},
+		{"/iprs/osr/" + h + "/web", "web", nil},
+		{"/osr/" + h + "/projects/web/prod", "projects/web/prod", nil},
+		{"/osr/" + h + "/", "", ErrInvalidSalt},
+		{"/osr/" + h + "/a//b", "", ErrInvalidSalt},
+		{"/osr/" + h + "/a/../b", "", ErrInvalidSalt},
+		{"/osr/" + h + "/web/", "", ErrInvalidSalt},
+		{"/osr/", "", ErrInvalidPath},
+		{"/osr/not-base58", "", ErrInvalidPath},
+		{"/ipfs/" + h, "", ErrInvalidPath},
+		{"/iprs/" + h, "", ErrInvalidPath},
+		{"osr/" + h, "", ErrInvalidPath},
+	}
+
+	for _, c := range cases {
+		ph, salt, err := ParsePath(c.path)
+		if err != c.err {
+			t.Errorf("%s: got %v, expected %v", c.path, err, c.err)
+			continue
+		} else if err != nil {
+			continue
+		}
+		if !bytes.Equal(ph, hash) || salt != c.salt {
+			t.Errorf("%s: parsed as %s %q", c.path, b58.Encode(ph), salt)
+		}
+
+		// Paths are rebuilt without the DHT prefix
+		path, err := PathFromHash(ph, salt)
+		if err != nil {
+			t.Errorf("%s: %s", c.path, err)
+		} else if Key(path) != c.path && path != c.path {
+			t.Errorf("%s: rebuilt as %s", c.path, path)
+		}
+	}
+
+	// Records are published at the path of their key and salt
+	data, err := (&Record{CID: testCID, Order: 1, Salt: "projects/web"}).Encode(sk, CBORCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := rec.Key(); err != nil || key != "/iprs/osr/"+h+"/projects/web" {
+		t.Errorf("record key %s (%v)", key, err)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	cases := []struct {
+		prefix string
+		salts  []string
+		salt   string
+		err    error
+	}{
+		{"", []string{"web", "projects/api"}, "_salts", nil},
+		{"projects", []string{"projects/web", "projects/api/v2"}, "projects/_salts", nil},
+		{"projects", nil, "projects/_salts", nil},
+		{"projects", []string{"web"}, "", ErrInvalidSalt},
+		{"projects", []string{"projects"}, "", ErrInvalidSalt},
+		{"projects", []string{"projects/a//b"}, "", ErrInvalidSalt},
+		{"projects/", nil, "", ErrInvalidSalt},
+	}
+
+	sk := testKey(t)
+	for _, c := range cases {
+		index, err := NewIndex(c.prefix, c.salts, 1)
+		if err != c.err {
+			t.Errorf("%q %q: got %v, expected %v", c.prefix, c.salts, err, c.err)
+			continue
+		} else if err != nil {
+			continue
+		}
+		if index.Salt != c.salt || !index.IsIndex() {
+			t.Errorf("%q: index salt %q", c.prefix, index.Salt)
+		}
+
+		data, err := index.Encode(sk, JSONCodec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec, err := Decode(data)
+		if err != nil {
+			t.Fatalf("%q: %s", c.prefix, err)
+		}
+		salts, err := dec.ListSalts()
+		if err != nil {
+			t.Errorf("%q: %s", c.prefix, err)
+		} else if len(salts) != len(c.salts) {
+			t.Errorf("%q: listed %q, expected %q", c.prefix, salts, c.salts)
+		}
+	}
+
+	rec := &Record{CID: testCID, Salt: "projects/web"}
+	if _, err := rec.ListSalts(); err != ErrNotIndex {
+		t.Errorf("regular record: got %v, expected ErrNotIndex", err)
+	}
+}
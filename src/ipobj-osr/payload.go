@@ -0,0 +1,58 @@
+package osr
+
+import (
+	"errors"
+	"strings"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+var ErrNoPayload error = errors.New("Record has no payload")
+var ErrInvalidPayload error = errors.New("Invalid payload, expected a CID, /ipfs/<cid> or /iprs/... path")
+
+// Parsed CID field of a record. Exactly one of Cid or Record is set.
+type Payload struct {
+	// Content identifier, for /ipfs/<cid> and bare CID payloads
+	Cid *cid.Cid
+
+	// Path to another record, for /iprs/... payloads
+	Record string
+}
+
+// Parse a payload string: a CID, /ipfs/<cid> or /iprs/... path
+func ParsePayload(s string) (*Payload, error) {
+	if s == "" {
+		return nil, ErrNoPayload
+	}
+
+	if strings.HasPrefix(s, "/"+Namespace+"/") {
+		_, _, err := ParsePath(s)
+		if err != nil {
+			return nil, ErrInvalidPayload
+		}
+		return &Payload{Record: s}, nil
+	}
+
+	s = strings.TrimPrefix(s, "/ipfs/")
+	c, err := cid.Decode(s)
+	if err != nil {
+		return nil, ErrInvalidPayload
+	}
+
+	return &Payload{Cid: c}, nil
+}
+
+func (p *Payload) String() string {
+	if p.Cid != nil {
+		return "/ipfs/" + p.Cid.String()
+	}
+	return p.Record
+}
+
+// Parse the CID field of the record
+func (r *Record) GetPayload() (*Payload, error) {
+	if r.IsSealed() {
+		return nil, ErrSealed
+	}
+	return ParsePayload(r.CID)
+}
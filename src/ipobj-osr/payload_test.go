@@ -0,0 +1,49 @@
+package osr
+
+import (
+	"testing"
+)
+
+func TestParsePayload(t *testing.T) {
+	path, err := Path("web", testKey(t).GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bare := testCID[len("/ipfs/"):]
+
+	cases := []struct {
+		payload  string
+		expected string
+		record   bool
+		err      error
+	}{
+		{testCID, testCID, false, nil},
+		{bare, testCID, false, nil},
+		{Key(path), Key(path), true, nil},
+		{Key(path) + "/sub/salt", Key(path) + "/sub/salt", true, nil},
+		{"", "", false, ErrNoPayload},
+		{"/ipfs/", "", false, ErrInvalidPayload},
+		{"/ipfs/not-a-cid", "", false, ErrInvalidPayload},
+		{"/ipns/" + bare, "", false, ErrInvalidPayload},
+		{"hello world", "", false, ErrInvalidPayload},
+		{"/" + Namespace + "/osr/not-base58", "", false, ErrInvalidPayload},
+		{Key(path) + "//salt", "", false, ErrInvalidPayload},
+		{path, "", false, ErrInvalidPayload},
+	}
+
+	for _, c := range cases {
+		p, err := ParsePayload(c.payload)
+		if err != c.err {
+			t.Errorf("%q: got %v, expected %v", c.payload, err, c.err)
+			continue
+		} else if err != nil {
+			continue
+		}
+		if p.String() != c.expected {
+			t.Errorf("%q: parsed as %s, expected %s", c.payload, p, c.expected)
+		}
+		if (p.Record != "") != c.record || (p.Cid != nil) == c.record {
+			t.Errorf("%q: parsed as %+v", c.payload, p)
+		}
+	}
+}
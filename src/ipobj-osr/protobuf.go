@@ -0,0 +1,49 @@
+package osr
+
+import (
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/multiformats/go-multicodec"
+)
+
+var HeaderProtobuf = multicodec.Header([]byte("/protobuf"))
+
+// Protocol buffers codec
+var ProtobufCodec Codec = protobufCodec{}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Header() []byte {
+	return HeaderProtobuf
+}
+
+func (protobufCodec) EncodeRecord(r *Record) ([]byte, error) {
+	br, err := toBinRecord(r)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(br)
+}
+
+func (protobufCodec) DecodeRecord(data []byte, r *Record) error {
+	var br binRecord
+	err := proto.Unmarshal(data, &br)
+	if err != nil {
+		return err
+	}
+	fromBinRecord(&br, r)
+	return nil
+}
+
+func (protobufCodec) EncodeSigned(sr *SignedRecord) ([]byte, error) {
+	return proto.Marshal(toBinSigned(sr))
+}
+
+func (protobufCodec) DecodeSigned(data []byte, sr *SignedRecord) error {
+	var bsr binSignedRecord
+	err := proto.Unmarshal(data, &bsr)
+	if err != nil {
+		return err
+	}
+	fromBinSigned(&bsr, sr)
+	return nil
+}
@@ -0,0 +1,69 @@
+package osr
+
+import (
+	"fmt"
+
+	"ipobj"
+)
+
+// OSRs as an ipobj record type for the /iprs namespace
+var RecordType ipobj.RecordType = recordType{}
+
+type recordType struct{}
+
+func (recordType) Namespace() string {
+	return Namespace
+}
+
+func (recordType) Key(value []byte) (string, error) {
+	rec, err := decode(value, nil, false)
+	if err != nil {
+		return "", err
+	}
+	return rec.Key()
+}
+
+func (recordType) Decode(key string, value []byte) (ipobj.DecodedRecord, error) {
+	rec, err := DecodeStrict(value, key)
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (recordType) Compare(a, b ipobj.DecodedRecord) (int, error) {
+	ra, ok := a.(*Record)
+	if !ok {
+		return 0, ErrIncomparable
+	}
+	rb, ok := b.(*Record)
+	if !ok {
+		return 0, ErrIncomparable
+	}
+	return Compare(ra, rb)
+}
+
+func (recordType) Order(rec ipobj.DecodedRecord) string {
+	r, ok := rec.(*Record)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%d", r.Order)
+}
+
+func (recordType) IsRevoked(rec ipobj.DecodedRecord) bool {
+	r, ok := rec.(*Record)
+	return ok && r.Revoked
+}
+
+func (recordType) Describe(rec ipobj.DecodedRecord) []string {
+	r, ok := rec.(*Record)
+	if !ok {
+		return nil
+	}
+	return r.Describe()
+}
+
+func init() {
+	ipobj.RegisterRecordType(RecordType)
+}
@@ -0,0 +1,70 @@
+package osr
+
+import (
+	"testing"
+
+	"ipobj"
+)
+
+func TestRecordType(t *testing.T) {
+	sk := testKey(t)
+
+	rec := &Record{CID: testCID, Order: 5, Salt: "type"}
+	data, err := rec.Encode(sk, CBORCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := Path("type", sk.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt, key, err := ipobj.RecordTypeOf(data)
+	if err != nil {
+		t.Fatal(err)
+	} else if rt != RecordType || key != Key(path) {
+		t.Fatalf("got record type %s and key %s, expected %s", rt.Namespace(), key, Key(path))
+	}
+	if found, err := ipobj.RecordTypeFor(key); err != nil || found != RecordType {
+		t.Errorf("record type for %s: %v", key, err)
+	}
+
+	dec, err := rt.Decode(key, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order := rt.Order(dec); order != "5" {
+		t.Errorf("order %s", order)
+	}
+	if rt.IsRevoked(dec) {
+		t.Errorf("record is revoked")
+	}
+	lines := rt.Describe(dec)
+	if len(lines) == 0 || lines[0] != "path:    "+Key(path) {
+		t.Errorf("described as %q", lines)
+	}
+
+	// The key of expired records is still known
+	expired := &Record{CID: testCID, Order: 6, Salt: "type", EOL: 1}
+	data, err = expired.Encode(sk, CBORCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, k, err := ipobj.RecordTypeOf(data); err != nil || k != key {
+		t.Errorf("expired record key %s (%v), expected %s", k, err, key)
+	}
+	if _, err := rt.Decode(key, data); err != ipobj.ErrExpired {
+		t.Errorf("expired record: got %v, expected ErrExpired", err)
+	}
+
+	data, err = NewTombstone("type", 7).Encode(sk, CBORCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead, err := rt.Decode(key, data)
+	if err != nil {
+		t.Fatal(err)
+	} else if !rt.IsRevoked(dead) {
+		t.Errorf("tombstone is not revoked")
+	}
+}
@@ -0,0 +1,65 @@
+package osr
+
+import (
+	"encoding/base64"
+	"errors"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+var ErrInvalidRotation error = errors.New("Invalid key rotation")
+var ErrNotRotation error = errors.New("Not a rotation record")
+
+// Create a rotation record designating next as successor for salt. It must
+// be encoded with the key being retired. If that key is itself a successor,
+// prev is its encoded rotation record, nil otherwise.
+func NewRotation(salt string, next ic.PubKey, order uint64, prev []byte) (*Record, error) {
+	pk, err := next.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Record{
+		Order:     order,
+		Salt:      salt,
+		Successor: base64.RawStdEncoding.EncodeToString(pk),
+		Rotation:  prev,
+	}, nil
+}
+
+func (r *Record) IsRotation() bool {
+	return r.Successor != ""
+}
+
+func (r *Record) GetSuccessor() (ic.PubKey, error) {
+	if !r.IsRotation() {
+		return nil, ErrNotRotation
+	}
+
+	pkd, err := base64.RawStdEncoding.DecodeString(r.Successor)
+	if err != nil {
+		return nil, err
+	}
+
+	return ic.UnmarshalPublicKey(pkd)
+}
+
+// Decode a rotation record and check it designates pk as successor. Returns
+// the rotation record.
+func VerifyRotation(rot []byte, pk ic.PubKey) (*Record, error) {
+	rec, err := Decode(rot)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := rec.GetSuccessor()
+	if err != nil {
+		return nil, err
+	}
+
+	if !next.Equals(pk) {
+		return nil, ErrInvalidRotation
+	}
+
+	return rec, nil
+}
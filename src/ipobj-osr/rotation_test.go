@@ -0,0 +1,109 @@
+package osr
+
+import (
+	"testing"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+// Encode and decode a record, failing the test on error
+func testRecord(t *testing.T, rec *Record, sk ic.PrivKey) *Record {
+	data, err := rec.Encode(sk, CBORCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dec
+}
+
+// Encode a rotation from sk to next, failing the test on error
+func testRotation(t *testing.T, sk, next ic.PrivKey, order uint64, prev []byte) []byte {
+	rot, err := NewRotation("rot", next.GetPublic(), order, prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := rot.Encode(sk, CBORCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func testCompare(t *testing.T, name string, a, b *Record, expected int) {
+	cmp, err := Compare(a, b)
+	if err != nil {
+		t.Fatalf("%s: %s", name, err)
+	} else if cmp != expected {
+		t.Errorf("%s: got %d, expected %d", name, cmp, expected)
+	}
+	cmp, err = Compare(b, a)
+	if err != nil {
+		t.Fatalf("%s: %s", name, err)
+	} else if cmp != -expected {
+		t.Errorf("%s reversed: got %d, expected %d", name, cmp, -expected)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	k0, k1, k2 := testKey(t), testKey(t), testKey(t)
+
+	rot1 := testRotation(t, k0, k1, 10, nil)
+	rot2 := testRotation(t, k1, k2, 20, rot1)
+
+	old := testRecord(t, &Record{CID: testCID, Order: 100, Salt: "rot"}, k0)
+	next := testRecord(t, &Record{CID: testCID, Order: 1, Salt: "rot", Rotation: rot1}, k1)
+	last := testRecord(t, &Record{CID: testCID, Order: 1, Salt: "rot", Rotation: rot2}, k2)
+
+	for _, rec := range []*Record{next, last} {
+		root, err := rec.GetRootPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		} else if !root.Equals(k0.GetPublic()) {
+			t.Errorf("root key is not the first key of the chain")
+		}
+	}
+
+	testCompare(t, "successor", next, old, 1)
+	testCompare(t, "second successor", last, next, 1)
+
+	// Rotation records must designate the record key
+	data, err := (&Record{CID: testCID, Salt: "rot", Rotation: rot1}).Encode(k2, CBORCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decode(data); err != ErrInvalidRotation {
+		t.Errorf("record from another key: got %v", err)
+	}
+	if _, err := VerifyRotation(rot1, k2.GetPublic()); err != ErrInvalidRotation {
+		t.Errorf("rotation to another key: got %v", err)
+	}
+}
+
+func TestRotationPinned(t *testing.T) {
+	k0, k1, k2, k3 := testKey(t), testKey(t), testKey(t), testKey(t)
+
+	first := testRotation(t, k0, k1, 10, nil)
+	competing := testRotation(t, k0, k2, 20, nil)
+	further := testRotation(t, k2, k3, 30, competing)
+
+	pinned := testRecord(t, &Record{CID: testCID, Order: 1, Salt: "rot", Rotation: first}, k1)
+	rec := testRecord(t, &Record{CID: testCID, Order: 100, Salt: "rot", Rotation: competing}, k2)
+	longer := testRecord(t, &Record{CID: testCID, Order: 100, Salt: "rot", Rotation: further}, k3)
+
+	testCompare(t, "competing rotation", pinned, rec, 1)
+	testCompare(t, "longer competing chain", pinned, longer, 1)
+
+	firstRec, err := Decode(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	competingRec, err := Decode(competing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCompare(t, "rotation records", firstRec, competingRec, 1)
+	testCompare(t, "record under competing rotation", firstRec, rec, 1)
+}
@@ -0,0 +1,260 @@
+package osr
+
+import (
+	"crypto/rand"
+	"errors"
+
+	"github.com/agl/ed25519/extra25519"
+	proto "github.com/gogo/protobuf/proto"
+	ic "github.com/libp2p/go-libp2p-crypto"
+	pb "github.com/libp2p/go-libp2p-crypto/pb"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Sealed payloads
+//
+// The payload of a record can be encrypted so only readers holding a key
+// can read it. The signature, order and path stay public so relays can
+// still validate, order and forward the record.
+//
+// The payload is sealed with NaCl secretbox under a 32 bytes content key and
+// stored as nonce (24 bytes) followed by the secretbox. The content key is
+// either a symmetric read key shared with the readers, or a random key
+// wrapped for each recipient public key:
+//
+// - Ed25519 recipients: ephemeral Curve25519 public key (32 bytes), nonce
+//   (24 bytes) and NaCl box of the content key to the Curve25519 form of the
+//   recipient key.
+// - RSA recipients: content key encrypted with the recipient key.
+//
+// Wrapped keys are not labelled, readers try each of them.
+
+const ReadKeySize = 32
+
+var ErrSealed error = errors.New("Record payload is sealed")
+var ErrNotSealed error = errors.New("Record payload is not sealed")
+var ErrCannotOpen error = errors.New("Cannot open sealed payload")
+var ErrUnsupportedRecipient error = errors.New("Unsupported recipient key type")
+
+func (r *Record) IsSealed() bool {
+	return r.SealedCID != nil
+}
+
+// Seal the payload with a symmetric read key
+func (r *Record) SealWithKey(key *[ReadKeySize]byte) error {
+	sealed, err := sealPayload(r.CID, key)
+	if err != nil {
+		return err
+	}
+
+	r.CID = ""
+	r.SealedCID = sealed
+	r.Recipients = nil
+	return nil
+}
+
+// Seal the payload to a list of recipients
+func (r *Record) SealTo(recipients []ic.PubKey) error {
+	var key [ReadKeySize]byte
+	_, err := rand.Read(key[:])
+	if err != nil {
+		return err
+	}
+
+	var wrapped [][]byte
+	for _, pk := range recipients {
+		w, err := wrapKey(&key, pk)
+		if err != nil {
+			return err
+		}
+		wrapped = append(wrapped, w)
+	}
+
+	sealed, err := sealPayload(r.CID, &key)
+	if err != nil {
+		return err
+	}
+
+	r.CID = ""
+	r.SealedCID = sealed
+	r.Recipients = wrapped
+	return nil
+}
+
+// Open a payload sealed with a symmetric read key
+func (r *Record) OpenWithKey(key *[ReadKeySize]byte) (string, error) {
+	if !r.IsSealed() {
+		return "", ErrNotSealed
+	}
+	return openPayload(r.SealedCID, key)
+}
+
+// Open a payload sealed to the public key of sk
+func (r *Record) Open(sk ic.PrivKey) (string, error) {
+	if !r.IsSealed() {
+		return "", ErrNotSealed
+	}
+
+	for _, w := range r.Recipients {
+		key, err := unwrapKey(w, sk)
+		if err != nil {
+			continue
+		}
+
+		payload, err := openPayload(r.SealedCID, key)
+		if err == nil {
+			return payload, nil
+		}
+	}
+
+	return "", ErrCannotOpen
+}
+
+func sealPayload(payload string, key *[ReadKeySize]byte) ([]byte, error) {
+	var nonce [24]byte
+	_, err := rand.Read(nonce[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return secretbox.Seal(nonce[:], []byte(payload), &nonce, key), nil
+}
+
+func openPayload(sealed []byte, key *[ReadKeySize]byte) (string, error) {
+	if len(sealed) < 24+secretbox.Overhead {
+		return "", ErrCannotOpen
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], sealed[:24])
+
+	payload, ok := secretbox.Open(nil, sealed[24:], &nonce, key)
+	if !ok {
+		return "", ErrCannotOpen
+	}
+
+	return string(payload), nil
+}
+
+type encrypter interface {
+	Encrypt(b []byte) ([]byte, error)
+}
+
+type decrypter interface {
+	Decrypt(b []byte) ([]byte, error)
+}
+
+func wrapKey(key *[ReadKeySize]byte, pk ic.PubKey) ([]byte, error) {
+	if enc, ok := pk.(encrypter); ok {
+		return enc.Encrypt(key[:])
+	}
+
+	edPub, err := ed25519PublicKey(pk)
+	if err != nil {
+		return nil, err
+	}
+
+	var curvePub [32]byte
+	if !extra25519.PublicKeyToCurve25519(&curvePub, edPub) {
+		return nil, ErrUnsupportedRecipient
+	}
+
+	ephPub, ephPriv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [24]byte
+	_, err = rand.Read(nonce[:])
+	if err != nil {
+		return nil, err
+	}
+
+	res := append(ephPub[:], nonce[:]...)
+	return box.Seal(res, key[:], &nonce, &curvePub, ephPriv), nil
+}
+
+func unwrapKey(wrapped []byte, sk ic.PrivKey) (*[ReadKeySize]byte, error) {
+	var key [ReadKeySize]byte
+
+	if dec, ok := sk.(decrypter); ok {
+		data, err := dec.Decrypt(wrapped)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) != ReadKeySize {
+			return nil, ErrCannotOpen
+		}
+		copy(key[:], data)
+		return &key, nil
+	}
+
+	edPriv, err := ed25519PrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+
+	var curvePriv [32]byte
+	extra25519.PrivateKeyToCurve25519(&curvePriv, edPriv)
+
+	if len(wrapped) < 32+24+box.Overhead {
+		return nil, ErrCannotOpen
+	}
+
+	var ephPub [32]byte
+	var nonce [24]byte
+	copy(ephPub[:], wrapped[:32])
+	copy(nonce[:], wrapped[32:56])
+
+	data, ok := box.Open(nil, wrapped[56:], &nonce, &ephPub, &curvePriv)
+	if !ok || len(data) != ReadKeySize {
+		return nil, ErrCannotOpen
+	}
+
+	copy(key[:], data)
+	return &key, nil
+}
+
+func ed25519PublicKey(pk ic.PubKey) (*[32]byte, error) {
+	data, err := pk.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var pbk pb.PublicKey
+	err = proto.Unmarshal(data, &pbk)
+	if err != nil {
+		return nil, err
+	}
+
+	if pbk.GetType() != pb.KeyType_Ed25519 || len(pbk.GetData()) != 32 {
+		return nil, ErrUnsupportedRecipient
+	}
+
+	var res [32]byte
+	copy(res[:], pbk.GetData())
+	return &res, nil
+}
+
+func ed25519PrivateKey(sk ic.PrivKey) (*[64]byte, error) {
+	data, err := sk.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var pbk pb.PrivateKey
+	err = proto.Unmarshal(data, &pbk)
+	if err != nil {
+		return nil, err
+	}
+
+	// The public key may be appended to the 64 bytes private key
+	if pbk.GetType() != pb.KeyType_Ed25519 || len(pbk.GetData()) < 64 {
+		return nil, ErrUnsupportedRecipient
+	}
+
+	var res [64]byte
+	copy(res[:], pbk.GetData())
+	return &res, nil
+}
@@ -0,0 +1,82 @@
+package osr
+
+import (
+	"crypto/rand"
+	"testing"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+func TestSealWithKey(t *testing.T) {
+	sk := testKey(t)
+	var key, other [ReadKeySize]byte
+	rand.Read(key[:])
+	rand.Read(other[:])
+
+	for name, codec := range testCodecs {
+		rec := &Record{CID: testCID, Order: 1, Salt: "sealed"}
+		err := rec.SealWithKey(&key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := rec.Encode(sk, codec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dec, err := Decode(data)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		} else if !dec.IsSealed() || dec.CID != "" {
+			t.Fatalf("%s: payload is not sealed", name)
+		}
+
+		payload, err := dec.OpenWithKey(&key)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+		} else if payload != testCID {
+			t.Errorf("%s: payload %s, expected %s", name, payload, testCID)
+		}
+		if _, err := dec.OpenWithKey(&other); err == nil {
+			t.Errorf("%s: opened with another key", name)
+		}
+	}
+}
+
+func TestSealTo(t *testing.T) {
+	sk := testKey(t)
+	keys := testKeys(t)
+	recipients := []ic.PubKey{keys["ed25519"].GetPublic(), keys["rsa"].GetPublic()}
+
+	rec := &Record{CID: testCID, Order: 1, Salt: "sealed"}
+	err := rec.SealTo(recipients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := rec.Encode(sk, ProtobufCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"ed25519", "rsa"} {
+		payload, err := dec.Open(keys[name])
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+		} else if payload != testCID {
+			t.Errorf("%s: payload %s, expected %s", name, payload, testCID)
+		}
+	}
+
+	if _, err := dec.Open(sk); err != ErrCannotOpen {
+		t.Errorf("other key: got %v, expected ErrCannotOpen", err)
+	}
+
+	err = rec.SealTo([]ic.PubKey{keys["secp256k1"].GetPublic()})
+	if err != ErrUnsupportedRecipient {
+		t.Errorf("secp256k1 recipient: got %v, expected ErrUnsupportedRecipient", err)
+	}
+}
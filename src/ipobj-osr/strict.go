@@ -0,0 +1,394 @@
+package osr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Strict decoding
+//
+// Records received from other peers are untrusted. DecodeStrict checks their
+// structure before decoding them and verifying their signature, and rejects:
+//
+//	- records larger than MaxRecordSize
+//	- records without the OSR header or without a known codec header
+//	- unknown or duplicate fields, and values nested deeper than the record
+//	  structure allows
+//	- public keys larger than MaxKeySize, which fits a 8192 bits RSA key
+//	- rotation chains longer than MaxRotationDepth, counting batch roots
+//
+// Each rejection has its own error, see IsRejected.
+
+// Maximum size of an encoded record, headers included
+const MaxRecordSize = 64 * 1024
+
+// Maximum size of a marshalled public key
+const MaxKeySize = 1100
+
+// Maximum number of nested rotation records
+const MaxRotationDepth = 8
+
+// Maximum nesting of values below a record field
+const maxNesting = 3
+
+var ErrRecordTooLarge error = errors.New("Record too large")
+var ErrMissingHeader error = errors.New("Record header missing")
+var ErrUnknownField error = errors.New("Unknown record field")
+var ErrDuplicateField error = errors.New("Duplicate record field")
+var ErrTooDeep error = errors.New("Record nested too deep")
+var ErrKeyTooLarge error = errors.New("Record public key too large")
+var ErrMalformed error = errors.New("Malformed record")
+
+// Check if err is a rejection from strict decoding, meaning the record was
+// malformed or abusive rather than merely invalid
+func IsRejected(err error) bool {
+	switch err {
+	case ErrRecordTooLarge, ErrMissingHeader, ErrUnknownField, ErrDuplicateField, ErrTooDeep, ErrKeyTooLarge, ErrMalformed, ErrUnknownCodec:
+		return true
+	}
+	return false
+}
+
+// Decode a record received from the network at path, rejecting records that
+// do not pass CheckStrict
+func DecodeStrict(rec []byte, path string) (*Record, error) {
+	err := CheckStrict(rec)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeForPath(rec, path)
+}
+
+// Check the structure of an encoded record without verifying it
+func CheckStrict(rec []byte) error {
+	return checkStrict(rec, 0)
+}
+
+func checkStrict(rec []byte, depth int) error {
+	if len(rec) > MaxRecordSize {
+		return ErrRecordTooLarge
+	} else if depth > MaxRotationDepth {
+		return ErrTooDeep
+	}
+
+	if !bytes.HasPrefix(rec, HeaderOSR) {
+		return ErrMissingHeader
+	}
+	rec = rec[len(HeaderOSR):]
+
+	codec := codecFor(rec)
+	if codec == nil {
+		return ErrMissingHeader
+	}
+	rec = rec[len(codec.Header()):]
+
+	err := checkFields(codec, rec, signedFields)
+	if err != nil {
+		return err
+	}
+
+	var sr SignedRecord
+	err = codec.DecodeSigned(rec, &sr)
+	if err != nil {
+		return ErrMalformed
+	}
+
+	for _, w := range sr.Witnesses {
+		if len(w.PublicKey) > MaxKeySize {
+			return ErrKeyTooLarge
+		}
+	}
+
+	err = checkFields(codec, sr.Record, recordFields)
+	if err != nil {
+		return err
+	}
+
+	if len(sr.Batch) > 0 {
+		err = checkStrict(sr.Batch, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	var ur Record
+	err = codec.DecodeRecord(sr.Record, &ur)
+	if err != nil {
+		return ErrMalformed
+	}
+
+	for _, pk := range append([]string{ur.PublicKey, ur.Successor}, ur.PublicKeys...) {
+		if base64.RawStdEncoding.DecodedLen(len(pk)) > MaxKeySize {
+			return ErrKeyTooLarge
+		}
+	}
+
+	if ur.Rotation != nil {
+		return checkStrict(ur.Rotation, depth+1)
+	}
+	return nil
+}
+
+// Fields allowed in a serialized structure, by name for JSON and CBOR and by
+// number for protobuf. Numbers map to true for repeated fields.
+type fieldSet struct {
+	names   map[string]bool
+	numbers map[uint64]bool
+}
+
+var recordFields = fieldsOf(binRecord{})
+var signedFields = fieldsOf(binSignedRecord{})
+
+// Collect the fields of a wire structure from its json and protobuf tags
+func fieldsOf(v interface{}) *fieldSet {
+	fs := &fieldSet{
+		names:   map[string]bool{},
+		numbers: map[uint64]bool{},
+	}
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag
+		fs.names[strings.Split(tag.Get("json"), ",")[0]] = true
+
+		pb := strings.Split(tag.Get("protobuf"), ",")
+		num, err := strconv.ParseUint(pb[1], 10, 64)
+		if err != nil {
+			panic(err)
+		}
+		fs.numbers[num] = pb[2] == "rep"
+	}
+	return fs
+}
+
+func checkFields(codec Codec, data []byte, fs *fieldSet) error {
+	switch codec {
+	case JSONCodec:
+		return checkJSONFields(data, fs)
+	case CBORCodec:
+		return checkCBORFields(data, fs)
+	case ProtobufCodec:
+		return checkProtobufFields(data, fs)
+	}
+	return ErrUnknownCodec
+}
+
+func checkJSONFields(data []byte, fs *fieldSet) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil || tok != json.Delim('{') {
+		return ErrMalformed
+	}
+
+	seen := map[string]bool{}
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return ErrMalformed
+		}
+		key := tok.(string)
+		if !fs.names[key] {
+			return ErrUnknownField
+		} else if seen[key] {
+			return ErrDuplicateField
+		}
+		seen[key] = true
+
+		err = skipJSONValue(dec)
+		if err != nil {
+			return err
+		}
+	}
+
+	tok, err = dec.Token()
+	if err != nil || tok != json.Delim('}') {
+		return ErrMalformed
+	}
+	return nil
+}
+
+func skipJSONValue(dec *json.Decoder) error {
+	var nesting int
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return ErrMalformed
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			nesting++
+			if nesting > maxNesting {
+				return ErrTooDeep
+			}
+		case json.Delim('}'), json.Delim(']'):
+			nesting--
+		}
+		if nesting == 0 {
+			return nil
+		}
+	}
+}
+
+func checkCBORFields(data []byte, fs *fieldSet) error {
+	major, n, data, err := cborHead(data)
+	if err != nil {
+		return err
+	} else if major != 5 {
+		return ErrMalformed
+	}
+
+	seen := map[string]bool{}
+	for i := uint64(0); i < n; i++ {
+		major, l, rest, err := cborHead(data)
+		if err != nil {
+			return err
+		} else if major != 3 || uint64(len(rest)) < l {
+			return ErrMalformed
+		}
+		key := string(rest[:l])
+		if !fs.names[key] {
+			return ErrUnknownField
+		} else if seen[key] {
+			return ErrDuplicateField
+		}
+		seen[key] = true
+
+		data, err = skipCBORValue(rest[l:], 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(data) != 0 {
+		return ErrMalformed
+	}
+	return nil
+}
+
+// Read a CBOR item head, returning the major type, its argument and the
+// remaining data. Indefinite lengths are not accepted.
+func cborHead(data []byte) (byte, uint64, []byte, error) {
+	if len(data) == 0 {
+		return 0, 0, nil, ErrMalformed
+	}
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), data, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, nil, ErrMalformed
+	}
+
+	if len(data) < size {
+		return 0, 0, nil, ErrMalformed
+	}
+	var arg uint64
+	for _, b := range data[:size] {
+		arg = arg<<8 | uint64(b)
+	}
+	return major, arg, data[size:], nil
+}
+
+func skipCBORValue(data []byte, nesting int) ([]byte, error) {
+	if nesting > maxNesting {
+		return nil, ErrTooDeep
+	}
+
+	major, arg, data, err := cborHead(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var items uint64
+	switch major {
+	case 0, 1, 7:
+		return data, nil
+	case 2, 3:
+		if uint64(len(data)) < arg {
+			return nil, ErrMalformed
+		}
+		return data[arg:], nil
+	case 4:
+		items = arg
+	case 5:
+		items = 2 * arg
+	case 6:
+		items = 1
+	}
+
+	for i := uint64(0); i < items; i++ {
+		data, err = skipCBORValue(data, nesting+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func checkProtobufFields(data []byte, fs *fieldSet) error {
+	seen := map[uint64]bool{}
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrMalformed
+		}
+		data = data[n:]
+
+		num := key >> 3
+		repeated, ok := fs.numbers[num]
+		if !ok {
+			return ErrUnknownField
+		} else if seen[num] && !repeated {
+			return ErrDuplicateField
+		}
+		seen[num] = true
+
+		var size uint64
+		switch key & 7 {
+		case 0:
+			_, n = binary.Uvarint(data)
+			if n <= 0 {
+				return ErrMalformed
+			}
+			size = uint64(n)
+		case 1:
+			size = 8
+		case 2:
+			l, n := binary.Uvarint(data)
+			if n <= 0 {
+				return ErrMalformed
+			}
+			data = data[n:]
+			size = l
+		case 5:
+			size = 4
+		default:
+			return ErrMalformed
+		}
+
+		if uint64(len(data)) < size {
+			return ErrMalformed
+		}
+		data = data[size:]
+	}
+	return nil
+}
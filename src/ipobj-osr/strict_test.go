@@ -0,0 +1,228 @@
+package osr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+// Well-formed records of every kind encoded with codec, by name, and the
+// public key of the compact record
+func testWellFormed(t *testing.T, codec Codec) (map[string][]byte, ic.PubKey) {
+	k0, k1, k2 := testKey(t), testKey(t), testKey(t)
+	recs := map[string][]byte{}
+
+	var err error
+	full := &Record{
+		CID:       testCID,
+		Order:     1,
+		Salt:      "strict",
+		NotBefore: 1,
+		EOL:       uint64(time.Now().Add(time.Hour).Unix()),
+		Prev:      []byte("previous"),
+	}
+	recs["full"], err = full.Encode(k0, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recs["tombstone"], err = NewTombstone("strict", 2).Encode(k0, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := NewIndex("strict", []string{"strict/a", "strict/b"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs["index"], err = index.Encode(k0, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed := &Record{CID: testCID, Order: 1, Salt: "strict"}
+	err = sealed.SealTo([]ic.PubKey{k1.GetPublic(), k2.GetPublic()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs["sealed"], err = sealed.Encode(k0, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recs["compact"], err = (&Record{CID: testCID, Order: 1, Salt: "strict"}).EncodeCompact(k0, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rot, err := NewRotation("strict", k1.GetPublic(), 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs["rotation"], err = rot.Encode(k0, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs["rotated"], err = (&Record{CID: testCID, Order: 2, Salt: "strict", Rotation: recs["rotation"]}).Encode(k1, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recs["witnessed"], err = AddWitness(recs["full"], k2, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multi := &Record{CID: testCID, Order: 1, Salt: "strict"}
+	err = multi.SetPublicKeys([]ic.PubKey{k0.GetPublic(), k1.GetPublic()}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := multi.EncodeMulti(codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sk := range []ic.PrivKey{k0, k1} {
+		data, err = Sign(data, sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	recs["multi"] = data
+
+	batch, err := EncodeBatch([]*Record{
+		{CID: testCID, Order: 1, Salt: "strict/a"},
+		{CID: testCID, Order: 1, Salt: "strict/b"},
+		{CID: testCID, Order: 1, Salt: "strict/c"},
+	}, k0, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs["batched"] = batch[2]
+
+	return recs, k0.GetPublic()
+}
+
+func TestStrictAccepts(t *testing.T) {
+	for cname, codec := range testCodecs {
+		recs, compactKey := testWellFormed(t, codec)
+		for rname, data := range recs {
+			name := cname + "/" + rname
+
+			err := CheckStrict(data)
+			if err != nil {
+				t.Errorf("%s: %s", name, err)
+				continue
+			}
+
+			var path string
+			if rname == "compact" {
+				path, err = CompactPath("strict", compactKey)
+			} else {
+				var rec *Record
+				rec, err = Decode(data)
+				if err != nil {
+					t.Fatalf("%s: %s", name, err)
+				}
+				path, err = rec.Path()
+			}
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+
+			_, err = DecodeStrict(data, path)
+			if err != nil {
+				t.Errorf("%s: %s", name, err)
+			}
+		}
+	}
+}
+
+// Replace the signed envelope of a record, keeping its headers
+func testReplaceEnvelope(data []byte, codec Codec, fn func([]byte) []byte) []byte {
+	hlen := len(HeaderOSR) + len(codec.Header())
+	res := append([]byte{}, data[:hlen]...)
+	return append(res, fn(data[hlen:])...)
+}
+
+func TestStrictRejects(t *testing.T) {
+	sk := testKey(t)
+	encode := func(codec Codec) []byte {
+		data, err := (&Record{CID: testCID, Order: 1, Salt: "strict"}).Encode(sk, codec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	protoField := func(num uint64) []byte {
+		var buf [binary.MaxVarintLen64]byte
+		return append(buf[:binary.PutUvarint(buf[:], num<<3)], 1)
+	}
+
+	cases := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"no header", encode(JSONCodec)[len(HeaderOSR):], ErrMissingHeader},
+		{"unknown codec", append(append([]byte{}, HeaderOSR...), []byte("\x05/xml\n<a/>")...), ErrMissingHeader},
+		{"too large", append(encode(CBORCodec), make([]byte, MaxRecordSize)...), ErrRecordTooLarge},
+		{"json unknown field", testReplaceEnvelope(encode(JSONCodec), JSONCodec, func(env []byte) []byte {
+			return append([]byte(`{"x":1,`), env[1:]...)
+		}), ErrUnknownField},
+		{"json duplicate field", testReplaceEnvelope(encode(JSONCodec), JSONCodec, func(env []byte) []byte {
+			return append([]byte(`{"sig":"AA",`), env[1:]...)
+		}), ErrDuplicateField},
+		{"json too deep", testReplaceEnvelope(encode(JSONCodec), JSONCodec, func(env []byte) []byte {
+			return append([]byte(`{"proof":[[[[1]]]],`), env[1:]...)
+		}), ErrTooDeep},
+		{"cbor truncated", testReplaceEnvelope(encode(CBORCodec), CBORCodec, func(env []byte) []byte {
+			return env[:len(env)-1]
+		}), ErrMalformed},
+		{"protobuf unknown field", testReplaceEnvelope(encode(ProtobufCodec), ProtobufCodec, func(env []byte) []byte {
+			return append(env, protoField(99)...)
+		}), ErrUnknownField},
+		{"protobuf duplicate field", testReplaceEnvelope(encode(ProtobufCodec), ProtobufCodec, func(env []byte) []byte {
+			return append(append(env, protoField(6)...), protoField(6)...)
+		}), ErrDuplicateField},
+	}
+
+	for _, c := range cases {
+		err := CheckStrict(c.data)
+		if err != c.expected {
+			t.Errorf("%s: got %v, expected %v", c.name, err, c.expected)
+		}
+		if !IsRejected(err) {
+			t.Errorf("%s: %v is not a rejection", c.name, err)
+		}
+	}
+}
+
+func TestStrictKeyTooLarge(t *testing.T) {
+	sk := testKey(t)
+	rec := &Record{CID: testCID, Order: 1, Salt: "strict"}
+	data, err := rec.Encode(sk, CBORCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sr, ur, err := decodeEnvelope(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ur.Successor = string(bytes.Repeat([]byte("A"), 2*MaxKeySize))
+	sr.Record, err = CBORCodec.EncodeRecord(ur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = encodeEnvelope(CBORCodec, sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckStrict(data); err != ErrKeyTooLarge {
+		t.Errorf("got %v, expected ErrKeyTooLarge", err)
+	}
+}
@@ -0,0 +1,12 @@
+package osr
+
+// Create a tombstone revoking the record for salt. Once published, it wins
+// over every normal record of the same key and salt, and of the keys it
+// replaced, see Compare.
+func NewTombstone(salt string, order uint64) *Record {
+	return &Record{
+		Order:   order,
+		Salt:    salt,
+		Revoked: true,
+	}
+}
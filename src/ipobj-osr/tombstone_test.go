@@ -0,0 +1,23 @@
+package osr
+
+import (
+	"testing"
+)
+
+func TestTombstone(t *testing.T) {
+	k0, k1 := testKey(t), testKey(t)
+	rot := testRotation(t, k0, k1, 10, nil)
+
+	rec := testRecord(t, &Record{CID: testCID, Order: 100, Salt: "rot"}, k0)
+	dead := testRecord(t, NewTombstone("rot", 1), k0)
+	next := testRecord(t, &Record{CID: testCID, Order: 1, Salt: "rot", Rotation: rot}, k1)
+
+	ts := NewTombstone("rot", 1)
+	ts.Rotation = rot
+	nextDead := testRecord(t, ts, k1)
+
+	testCompare(t, "tombstone of the same key", dead, rec, 1)
+	testCompare(t, "tombstone of a retired key", next, dead, 1)
+	testCompare(t, "tombstone of a successor key", nextDead, rec, 1)
+	testCompare(t, "tombstones of a successor key", nextDead, next, 1)
+}
@@ -0,0 +1,56 @@
+package osr
+
+import (
+	"errors"
+
+	"ipobj"
+
+	record "github.com/libp2p/go-libp2p-record"
+)
+
+// DHT namespace OSRs are published under: /iprs/osr/...
+const Namespace = "iprs"
+
+var ErrKeyMismatch error = errors.New("Record does not match its key")
+var ErrNoValidRecord error = ipobj.ErrNoValidRecord
+
+// Validator for the DHT namespace. Records are self-signed, the DHT does not
+// need to sign them.
+var ValidChecker = &record.ValidChecker{
+	Func: Validate,
+	Sign: false,
+}
+
+// Check value is a valid OSR for key
+func Validate(key string, value []byte) error {
+	_, err := DecodeStrict(value, key)
+	return err
+}
+
+// DHT selector, pick the most recent valid record
+func Select(key string, values [][]byte) (int, error) {
+	var best int = -1
+	var bestRec *Record
+
+	for i, value := range values {
+		rec, err := DecodeStrict(value, key)
+		if err != nil {
+			continue
+		}
+
+		if bestRec != nil {
+			cmp, err := Compare(rec, bestRec)
+			if err != nil || cmp <= 0 {
+				continue
+			}
+		}
+
+		best = i
+		bestRec = rec
+	}
+
+	if best < 0 {
+		return 0, ErrNoValidRecord
+	}
+	return best, nil
+}
@@ -0,0 +1,171 @@
+package osr
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+	"github.com/multiformats/go-multicodec"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// Witnesses
+//
+// Third parties can attest that they saw a record at a given time by adding
+// a witness signature to its envelope. Witnesses sign HeaderWitness, the
+// record hash and the time as a 64 bits big endian Unix timestamp. The
+// record hash (see Hash) covers the whole envelope but the witnesses, so a
+// witness signature is bound to the record signatures and batch proof.
+// Witnesses cannot change the record, and Decode ignores them: use
+// Witnesses or RequireWitnesses to verify them. AddWitness does not verify
+// the record, witnesses should decode it before signing.
+//
+// Witnesses are left out of the record hash (see Hash), so adding one keeps
+// the record address and the Prev field of the next version valid. The hash
+// is computed on the envelope as encoded by this package.
+
+var HeaderWitness = multicodec.Header([]byte("/ipfs/record/mildred-osr-witness"))
+
+var ErrNotEnoughWitnesses error = errors.New("Not enough known witnesses")
+
+// Witness signature as carried in the signed envelope
+type Witness struct {
+	PublicKey []byte
+	Time      uint64
+	Signature []byte
+}
+
+// A verified witness signature
+type Attestation struct {
+	PublicKey ic.PubKey
+	Time      time.Time
+}
+
+// Add a witness signature made with sk at time t to an encoded record
+func AddWitness(rec []byte, sk ic.PrivKey, t time.Time) ([]byte, error) {
+	codec, sr, _, err := decodeEnvelope(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, err := sk.GetPublic().Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	w := Witness{
+		PublicKey: pk,
+		Time:      uint64(t.Unix()),
+	}
+	hash, err := witnessedHash(codec, sr)
+	if err != nil {
+		return nil, err
+	}
+	w.Signature, err = sk.Sign(witnessStatement(hash, w.Time))
+	if err != nil {
+		return nil, err
+	}
+
+	sr.Witnesses = append(sr.Witnesses, w)
+	return encodeEnvelope(codec, sr)
+}
+
+// List the valid witness signatures of an encoded record. Invalid signatures
+// and signatures dated more than MaxClockSkew in the future are ignored. The
+// record itself is not verified, see Decode.
+func Witnesses(rec []byte) ([]Attestation, error) {
+	codec, sr, _, err := decodeEnvelope(rec)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := witnessedHash(codec, sr)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := time.Now().Add(MaxClockSkew).Unix()
+
+	var res []Attestation
+	for _, w := range sr.Witnesses {
+		if w.Time > uint64(limit) {
+			continue
+		}
+
+		pk, err := ic.UnmarshalPublicKey(w.PublicKey)
+		if err != nil {
+			continue
+		}
+
+		statement := witnessStatement(hash, w.Time)
+		if ok, err := pk.Verify(statement, w.Signature); err != nil || !ok {
+			continue
+		}
+
+		res = append(res, Attestation{
+			PublicKey: pk,
+			Time:      time.Unix(int64(w.Time), 0),
+		})
+	}
+	return res, nil
+}
+
+// Check an encoded record has valid signatures from at least k distinct
+// witnesses among known
+func RequireWitnesses(rec []byte, known []ic.PubKey, k int) error {
+	atts, err := Witnesses(rec)
+	if err != nil {
+		return err
+	}
+
+	seen := map[int]bool{}
+	for _, att := range atts {
+		for i, pk := range known {
+			if pk.Equals(att.PublicKey) {
+				seen[i] = true
+			}
+		}
+	}
+
+	if len(seen) < k {
+		return ErrNotEnoughWitnesses
+	}
+	return nil
+}
+
+// Encoded record without its witness signatures, as hashed by Hash and
+// served as object. Records without witnesses are returned unchanged.
+func StripWitnesses(rec []byte) ([]byte, error) {
+	codec, sr, _, err := decodeEnvelope(rec)
+	if err != nil {
+		return nil, err
+	} else if len(sr.Witnesses) == 0 {
+		return rec, nil
+	}
+
+	sr.Witnesses = nil
+	return encodeEnvelope(codec, sr)
+}
+
+// Hash of the envelope without its witnesses, the same as Hash for records
+// encoded by this package
+func witnessedHash(codec Codec, sr *SignedRecord) (mh.Multihash, error) {
+	stripped := *sr
+	stripped.Witnesses = nil
+	data, err := encodeEnvelope(codec, &stripped)
+	if err != nil {
+		return nil, err
+	}
+	return mh.Sum(data, mh.SHA2_256, -1)
+}
+
+// Bytes signed by witnesses for a record hash
+func witnessStatement(hash mh.Multihash, t uint64) []byte {
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], t)
+
+	var res []byte
+	res = append(res, HeaderWitness...)
+	res = append(res, hash...)
+	return append(res, ts[:]...)
+}
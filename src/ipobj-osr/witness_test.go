@@ -0,0 +1,133 @@
+package osr
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+func TestWitnesses(t *testing.T) {
+	sk, w1, w2, other := testKey(t), testKey(t), testKey(t), testKey(t)
+	known := []ic.PubKey{w1.GetPublic(), w2.GetPublic()}
+
+	for name, codec := range testCodecs {
+		data, err := (&Record{CID: testCID, Order: 1, Salt: "wit"}).Encode(sk, codec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		now := time.Now()
+		for _, w := range []ic.PrivKey{w1, other} {
+			data, err = AddWitness(data, w, now)
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+		}
+		// Dated too far in the future
+		data, err = AddWitness(data, w2, now.Add(2*MaxClockSkew))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		if _, err := Decode(data); err != nil {
+			t.Errorf("%s: witnessed record: %s", name, err)
+		}
+
+		atts, err := Witnesses(data)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		} else if len(atts) != 2 {
+			t.Errorf("%s: %d witnesses, expected 2", name, len(atts))
+		} else if atts[0].Time.Unix() != now.Unix() {
+			t.Errorf("%s: witness time %v, expected %v", name, atts[0].Time, now)
+		}
+
+		if err := RequireWitnesses(data, known, 1); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+		if err := RequireWitnesses(data, known, 2); err != ErrNotEnoughWitnesses {
+			t.Errorf("%s: got %v, expected ErrNotEnoughWitnesses", name, err)
+		}
+	}
+}
+
+func TestWitnessSignatures(t *testing.T) {
+	sk, w := testKey(t), testKey(t)
+
+	for name, codec := range testCodecs {
+		data, err := (&Record{CID: testCID, Order: 1, Salt: "wit"}).Encode(sk, codec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err = AddWitness(data, w, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Same record bytes under another signature
+		c, sr, _, err := decodeEnvelope(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sr.Signature = append([]byte{}, sr.Signature...)
+		sr.Signature[0] ^= 1
+		forged, err := encodeEnvelope(c, sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if atts, err := Witnesses(forged); err != nil {
+			t.Errorf("%s: %s", name, err)
+		} else if len(atts) != 0 {
+			t.Errorf("%s: witness of another signature accepted", name)
+		}
+	}
+}
+
+func TestWitnessHash(t *testing.T) {
+	sk, w := testKey(t), testKey(t)
+
+	for name, codec := range testCodecs {
+		data, err := (&Record{CID: testCID, Order: 1, Salt: "wit"}).Encode(sk, codec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		witnessed, err := AddWitness(data, w, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stripped, err := StripWitnesses(witnessed)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		} else if !bytes.Equal(stripped, data) {
+			t.Errorf("%s: stripped record differs from the original", name)
+		}
+
+		hash, err := Hash(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		whash, err := Hash(witnessed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(hash, whash) {
+			t.Errorf("%s: witness changed the record hash", name)
+		}
+
+		addr, err := RecordObjAddr(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		waddr, err := RecordObjAddr(witnessed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(addr, waddr) {
+			t.Errorf("%s: witness changed the record address", name)
+		}
+	}
+}
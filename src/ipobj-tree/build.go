@@ -0,0 +1,172 @@
+package tree
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"ipobj"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+// Size of the file chunks
+const ChunkSize = 256 * 1024
+
+// Maximum number of links of a file node, keeping nodes under a block
+const MaxLinks = 174
+
+// A built node, as linked from its parent
+type built struct {
+	addr ipobj.ObjAddr
+	// Size of the file content below the node
+	size uint64
+	// Size of the blocks below the node, including its own
+	tsize uint64
+}
+
+// Store the content of r as a file and return its address and size. Files
+// of a single chunk are stored as a raw block.
+func BuildFile(s Store, r io.Reader) (ipobj.ObjAddr, uint64, error) {
+	n, err := buildFile(s, r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return n.addr, n.size, nil
+}
+
+func buildFile(s Store, r io.Reader) (built, error) {
+	var nodes []built
+	buf := make([]byte, ChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			chunk := append([]byte{}, buf[:n]...)
+			addr, err := s.Put(chunk, cid.Raw)
+			if err != nil {
+				return built{}, err
+			}
+			nodes = append(nodes, built{addr, uint64(n), uint64(n)})
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return built{}, err
+		}
+	}
+
+	if len(nodes) == 0 {
+		return putFileNode(s, nil)
+	}
+
+	for len(nodes) > 1 {
+		var parents []built
+		for len(nodes) > 0 {
+			count := len(nodes)
+			if count > MaxLinks {
+				count = MaxLinks
+			}
+			n, err := putFileNode(s, nodes[:count])
+			if err != nil {
+				return built{}, err
+			}
+			parents = append(parents, n)
+			nodes = nodes[count:]
+		}
+		nodes = parents
+	}
+	return nodes[0], nil
+}
+
+func putFileNode(s Store, children []built) (built, error) {
+	fs := ipobj.UnixFSData{Type: ipobj.UnixFSFile}
+	var node ipobj.DAGNode
+	var tsize uint64
+	for _, c := range children {
+		fs.FileSize += c.size
+		fs.BlockSizes = append(fs.BlockSizes, c.size)
+		node.Links = append(node.Links, ipobj.DAGLink{Hash: c.addr, Size: c.tsize})
+		tsize += c.tsize
+	}
+	return putNode(s, &node, &fs, tsize)
+}
+
+func putNode(s Store, node *ipobj.DAGNode, fs *ipobj.UnixFSData, tsize uint64) (built, error) {
+	node.Data = fs.Encode()
+	block := node.Encode()
+	addr, err := s.Put(block, cid.DagProtobuf)
+	if err != nil {
+		return built{}, err
+	}
+	return built{addr, fs.FileSize, tsize + uint64(len(block))}, nil
+}
+
+// Store the local directory dir with its files, subdirectories and symbolic
+// links, and return its address. Other special files are ignored.
+func BuildDir(s Store, dir string) (ipobj.ObjAddr, error) {
+	n, err := buildDir(s, dir)
+	if err != nil {
+		return nil, err
+	}
+	return n.addr, nil
+}
+
+func buildDir(s Store, dir string) (built, error) {
+	// Entries are sorted by name
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return built{}, err
+	}
+
+	var node ipobj.DAGNode
+	var tsize uint64
+	for _, info := range infos {
+		file := filepath.Join(dir, info.Name())
+
+		var child built
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			child, err = buildDir(s, file)
+		case mode.IsRegular():
+			child, err = buildRegular(s, file)
+		case mode&os.ModeSymlink != 0:
+			child, err = buildSymlink(s, file)
+		default:
+			continue
+		}
+		if err != nil {
+			return built{}, err
+		}
+
+		node.Links = append(node.Links, ipobj.DAGLink{
+			Hash: child.addr,
+			Name: info.Name(),
+			Size: child.tsize,
+		})
+		tsize += child.tsize
+	}
+
+	return putNode(s, &node, &ipobj.UnixFSData{Type: ipobj.UnixFSDirectory}, tsize)
+}
+
+func buildRegular(s Store, file string) (built, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return built{}, err
+	}
+	defer f.Close()
+
+	return buildFile(s, f)
+}
+
+func buildSymlink(s Store, file string) (built, error) {
+	target, err := os.Readlink(file)
+	if err != nil {
+		return built{}, err
+	}
+	return putNode(s, &ipobj.DAGNode{}, &ipobj.UnixFSData{
+		Type: ipobj.UnixFSSymlink,
+		Data: []byte(target),
+	}, 0)
+}
@@ -0,0 +1,179 @@
+package tree
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"ipobj"
+
+	cid "github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// Storage of the blocks of a tree
+type Store interface {
+	// Store a block encoded with codec (cid.Raw or cid.DagProtobuf) and
+	// return its address
+	Put(block []byte, codec uint64) (ipobj.ObjAddr, error)
+
+	// Get a block, as ipobj.Peer.GetObject
+	GetObject(obj ipobj.ObjAddr) (io.Reader, error)
+}
+
+// Address of a block encoded with codec
+func BlockAddr(block []byte, codec uint64) (ipobj.ObjAddr, error) {
+	hash, err := mh.Sum(block, mh.SHA2_256, -1)
+	if err != nil {
+		return nil, err
+	}
+	return ipobj.ObjAddr(cid.NewCidV1(codec, hash).Bytes()), nil
+}
+
+// Block getter reading from a store, to walk a local tree
+func Getter(s Store) ipobj.BlockGetter {
+	return func(ctx context.Context, obj ipobj.ObjAddr) (io.Reader, error) {
+		return s.GetObject(obj)
+	}
+}
+
+// In memory store
+type MemStore struct {
+	lock   sync.Mutex
+	blocks map[string][]byte
+}
+
+func NewMemStore() *MemStore {
+	return &MemStore{
+		blocks: map[string][]byte{},
+	}
+}
+
+func (s *MemStore) Put(block []byte, codec uint64) (ipobj.ObjAddr, error) {
+	addr, err := BlockAddr(block, codec)
+	if err != nil {
+		return nil, err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.blocks[string(addr)] = block
+	return addr, nil
+}
+
+func (s *MemStore) GetObject(obj ipobj.ObjAddr) (io.Reader, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	block, ok := s.blocks[string(obj)]
+	if !ok {
+		return nil, ipobj.NoObject
+	}
+	return ipobj.BytesToReader(block), nil
+}
+
+// List the stored blocks
+func (s *MemStore) Addrs() []ipobj.ObjAddr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var addrs []ipobj.ObjAddr
+	for addr := range s.blocks {
+		addrs = append(addrs, ipobj.ObjAddr(addr))
+	}
+	return addrs
+}
+
+// Store keeping each block in a file named after its address
+type DirStore struct {
+	Dir string
+}
+
+func NewDirStore(dir string) (*DirStore, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &DirStore{dir}, nil
+}
+
+func (s *DirStore) file(obj ipobj.ObjAddr) string {
+	return filepath.Join(s.Dir, obj.String())
+}
+
+func (s *DirStore) Put(block []byte, codec uint64) (ipobj.ObjAddr, error) {
+	addr, err := BlockAddr(block, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	file := s.file(addr)
+	if _, err := os.Stat(file); err == nil {
+		return addr, nil
+	}
+
+	// Write to a temporary file and rename it so blocks are never partial
+	tmp, err := ioutil.TempFile(s.Dir, ".block")
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.Write(block)
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return addr, nil
+}
+
+func (s *DirStore) GetObject(obj ipobj.ObjAddr) (io.Reader, error) {
+	block, err := ioutil.ReadFile(s.file(obj))
+	if os.IsNotExist(err) {
+		return nil, ipobj.NoObject
+	} else if err != nil {
+		return nil, err
+	}
+	return ipobj.BytesToReader(block), nil
+}
+
+// List the stored blocks. Temporary files of interrupted writes are skipped.
+func (s *DirStore) Addrs() ([]ipobj.ObjAddr, error) {
+	files, err := ioutil.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []ipobj.ObjAddr
+	for _, f := range files {
+		if !f.Mode().IsRegular() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		addr, err := ipobj.ParseObjAddr(f.Name())
+		if err != nil {
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// Peer serving the blocks of a store to the network
+type Peer struct {
+	ipobj.NullPeerType
+	Store Store
+}
+
+func NewPeer(s Store) *Peer {
+	return &Peer{Store: s}
+}
+
+func (p *Peer) GetObject(obj ipobj.ObjAddr) (io.Reader, error) {
+	return p.Store.GetObject(obj)
+}
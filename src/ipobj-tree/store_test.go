@@ -0,0 +1,99 @@
+package tree
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"ipobj"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+func TestDirStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewDirStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := []struct {
+		data  []byte
+		codec uint64
+	}{
+		{[]byte("content"), cid.Raw},
+		{[]byte{}, cid.Raw},
+		{(&ipobj.DAGNode{Data: []byte("node")}).Encode(), cid.DagProtobuf},
+		// Stored twice
+		{[]byte("content"), cid.Raw},
+	}
+
+	stored := map[string]bool{}
+	for _, b := range blocks {
+		addr, err := store.Put(b.data, b.codec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := BlockAddr(b.data, b.codec)
+		if err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(addr, expected) {
+			t.Errorf("%q: stored at %s, expected %s", b.data, addr, expected)
+		}
+		stored[string(addr)] = true
+
+		r, err := store.GetObject(addr)
+		if err != nil {
+			t.Fatalf("%q: %s", b.data, err)
+		}
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(data, b.data) {
+			t.Errorf("%q: read %q", b.data, data)
+		}
+	}
+
+	// One file per block, no temporary file left by the renames
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(files) != len(stored) {
+		t.Errorf("%d files for %d blocks", len(files), len(stored))
+	}
+
+	addrs, err := store.Addrs()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(addrs) != len(stored) {
+		t.Errorf("listed %d blocks, expected %d", len(addrs), len(stored))
+	}
+	for _, addr := range addrs {
+		if !stored[string(addr)] {
+			t.Errorf("listed unknown block %s", addr)
+		}
+	}
+
+	// Interrupted writes are not listed
+	err = ioutil.WriteFile(dir+"/.block123", []byte("partial"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addrs, err := store.Addrs(); err != nil || len(addrs) != len(stored) {
+		t.Errorf("listed %d blocks with a temporary file (%v)", len(addrs), err)
+	}
+
+	missing, err := BlockAddr([]byte("missing"), cid.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetObject(missing); err != ipobj.NoObject {
+		t.Errorf("missing block: got %v, expected NoObject", err)
+	}
+}
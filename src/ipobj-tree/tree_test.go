@@ -0,0 +1,111 @@
+package tree
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildFile(t *testing.T) {
+	store := NewMemStore()
+	ctx := context.Background()
+
+	for _, size := range []int{0, 10, ChunkSize, 3*ChunkSize + 1} {
+		content := bytes.Repeat([]byte("0123456789"), size/10+1)[:size]
+		addr, n, err := BuildFile(store, bytes.NewReader(content))
+		if err != nil {
+			t.Fatalf("%d bytes: %s", size, err)
+		} else if n != uint64(size) {
+			t.Errorf("%d bytes: built size %d", size, n)
+		}
+
+		r, err := Open(ctx, Getter(store), addr, 0)
+		if err != nil {
+			t.Fatalf("%d bytes: %s", size, err)
+		}
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%d bytes: %s", size, err)
+		} else if !bytes.Equal(data, content) {
+			t.Errorf("%d bytes: read %d different bytes", size, len(data))
+		}
+	}
+}
+
+func TestBuildDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = os.MkdirAll(filepath.Join(dir, "sub", "empty"), 0755)
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0644)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("world!"), 0644)
+	}
+	if err == nil {
+		err = os.Symlink("sub/b.txt", filepath.Join(dir, "link"))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewMemStore()
+	root, err := BuildDir(store, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := map[string]*Entry{}
+	var paths []string
+	err = Walk(context.Background(), Getter(store), root, func(p string, e *Entry) error {
+		entries[p] = e
+		paths = append(paths, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"", "a.txt", "link", "sub", "sub/b.txt", "sub/empty"}
+	if len(paths) != len(expected) {
+		t.Fatalf("walked %v, expected %v", paths, expected)
+	}
+	for i, p := range expected {
+		if paths[i] != p {
+			t.Errorf("walked %v, expected %v", paths, expected)
+			break
+		}
+	}
+
+	if e := entries["sub/b.txt"]; e.Type != File || e.Size != 6 {
+		t.Errorf("file entry %+v", e)
+	}
+	if e := entries["link"]; e.Type != Symlink || e.Target != "sub/b.txt" {
+		t.Errorf("symlink entry %+v", e)
+	}
+	if e := entries["sub/empty"]; e.Type != Directory {
+		t.Errorf("directory entry %+v", e)
+	}
+
+	// Skipping a directory skips its content
+	var walked []string
+	err = Walk(context.Background(), Getter(store), root, func(p string, e *Entry) error {
+		walked = append(walked, p)
+		if p == "sub" {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(walked) != 4 {
+		t.Errorf("walked %v with sub skipped", walked)
+	}
+}
@@ -0,0 +1,132 @@
+package tree
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"path/filepath"
+
+	"ipobj"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+var ErrUnsupportedNode error = errors.New("Unsupported tree node")
+var ErrInvalidName error = errors.New("Invalid directory entry name")
+
+type EntryType int
+
+const (
+	File EntryType = iota
+	Directory
+	Symlink
+)
+
+// A node of a tree
+type Entry struct {
+	Addr ipobj.ObjAddr
+	Type EntryType
+	// Content size of files
+	Size uint64
+	// Target of symbolic links
+	Target string
+}
+
+// Called for each entry of a tree with its slash separated path relative to
+// the root, the root having an empty path. Returning filepath.SkipDir for a
+// directory skips its content.
+type WalkFunc func(path string, entry *Entry) error
+
+// Walk the tree at root, fetching the directory nodes with get, such as
+// ipobj.Network.GetObject. File content is not fetched, see Open.
+func Walk(ctx context.Context, get ipobj.BlockGetter, root ipobj.ObjAddr, fn WalkFunc) error {
+	err := walk(ctx, get, "", root, fn)
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+func walk(ctx context.Context, get ipobj.BlockGetter, p string, addr ipobj.ObjAddr, fn WalkFunc) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	entry, node, err := readEntry(ctx, get, addr)
+	if err != nil {
+		return err
+	}
+
+	err = fn(p, entry)
+	if err != nil || entry.Type != Directory {
+		return err
+	}
+
+	for _, l := range node.Links {
+		if !validName(l.Name) {
+			return ErrInvalidName
+		}
+		err = walk(ctx, get, path.Join(p, l.Name), l.Hash, fn)
+		if err == filepath.SkipDir {
+			continue
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fetch and decode a node
+func readEntry(ctx context.Context, get ipobj.BlockGetter, addr ipobj.ObjAddr) (*Entry, *ipobj.DAGNode, error) {
+	block, err := ipobj.GetBlock(ctx, get, addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := cid.Cast(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.Type() == cid.Raw {
+		return &Entry{Addr: addr, Type: File, Size: uint64(len(block))}, nil, nil
+	} else if c.Type() != cid.DagProtobuf {
+		return nil, nil, ErrUnsupportedNode
+	}
+
+	node, err := ipobj.DecodeDAGNode(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	fs, err := ipobj.DecodeUnixFSData(node.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry := &Entry{Addr: addr}
+	switch fs.Type {
+	case ipobj.UnixFSRaw, ipobj.UnixFSFile:
+		entry.Type = File
+		entry.Size = fs.FileSize
+	case ipobj.UnixFSDirectory:
+		entry.Type = Directory
+	case ipobj.UnixFSSymlink:
+		entry.Type = Symlink
+		entry.Target = string(fs.Data)
+	default:
+		return nil, nil, ErrUnsupportedNode
+	}
+	return entry, node, nil
+}
+
+// Entry names are single path segments
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && path.Base(name) == name
+}
+
+// Stream the content of a file of a tree, fetching its blocks with get.
+// Fails with ipobj.ErrObjectTooLarge past maxSize bytes, 0 for no limit.
+func Open(ctx context.Context, get ipobj.BlockGetter, file ipobj.ObjAddr, maxSize int64) (io.Reader, error) {
+	return ipobj.NewDAGReader(ctx, get, file, maxSize)
+}
@@ -0,0 +1,127 @@
+package ipobj
+
+import (
+	"errors"
+	"strings"
+
+	cid "github.com/ipfs/go-cid"
+	ma "github.com/multiformats/go-multiaddr"
+	mb "github.com/multiformats/go-multibase"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// Text forms
+//
+// Object addresses are written as CIDs, in multibase for CIDv1, and may be
+// prefixed by /ipfs/. Peer addresses are multiaddrs, and peers are written
+// /p2p/<id> with the base58 multihash of their id, optionally prefixed by a
+// multiaddr to reach them, as other IPFS tools do.
+
+var ErrNotRecordAddr error = errors.New("Not a record address")
+var ErrInvalidPeer error = errors.New("Invalid peer address, expected [<multiaddr>]/p2p/<id>")
+
+func (a ObjAddr) String() string {
+	c, err := cid.Cast(a)
+	if err != nil {
+		return multibaseString(a)
+	}
+	return c.String()
+}
+
+// Parse a CID, optionally prefixed by /ipfs/
+func ParseObjAddr(s string) (ObjAddr, error) {
+	c, err := cid.Decode(strings.TrimPrefix(s, "/ipfs/"))
+	if err != nil {
+		return nil, err
+	}
+	return ObjAddr(c.Bytes()), nil
+}
+
+// Record key of an address created by NewRecordObjAddr
+func RecordKey(a ObjAddr) (string, error) {
+	c, err := cid.Cast(a)
+	if err != nil {
+		return "", err
+	} else if c.Type() != RecordCidCode {
+		return "", ErrNotRecordAddr
+	}
+	h, err := mh.Decode(c.Hash())
+	if err != nil {
+		return "", err
+	} else if h.Code != RecordMultihashCode {
+		return "", ErrNotRecordAddr
+	}
+	return string(h.Digest), nil
+}
+
+func (a PeerAddr) String() string {
+	m, err := ma.NewMultiaddrBytes(a)
+	if err != nil {
+		return multibaseString(a)
+	}
+	return m.String()
+}
+
+func ParsePeerAddr(s string) (PeerAddr, error) {
+	m, err := ma.NewMultiaddr(s)
+	if err != nil {
+		return nil, err
+	}
+	return PeerAddr(m.Bytes()), nil
+}
+
+// Peer id as /p2p/<id>
+func PeerIdString(id []byte) string {
+	return "/p2p/" + mh.Multihash(id).B58String()
+}
+
+func (p *PeerInfo) String() string {
+	return PeerIdString(p.Id)
+}
+
+// Addresses of the peer, each followed by /p2p/<id>
+func (p *PeerInfo) Multiaddrs() []string {
+	var res []string
+	for _, a := range p.Addrs {
+		res = append(res, a.String()+PeerIdString(p.Id))
+	}
+	return res
+}
+
+// Parse a peer as [<multiaddr>]/p2p/<id>, also accepting the older /ipfs/<id>
+func ParsePeerInfo(s string) (*PeerInfo, error) {
+	i := strings.LastIndex(s, "/p2p/")
+	if j := strings.LastIndex(s, "/ipfs/"); j > i {
+		i = j
+	}
+	if i < 0 {
+		return nil, ErrInvalidPeer
+	}
+
+	parts := strings.Split(s[i+1:], "/")
+	if len(parts) != 2 {
+		return nil, ErrInvalidPeer
+	}
+	id, err := mh.FromB58String(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	p := &PeerInfo{Id: []byte(id)}
+	if i > 0 {
+		addr, err := ParsePeerAddr(s[:i])
+		if err != nil {
+			return nil, err
+		}
+		p.Addrs = append(p.Addrs, addr)
+	}
+	return p, nil
+}
+
+func multibaseString(data []byte) string {
+	s, err := mb.Encode(mb.Base58BTC, data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package ipobj
+
+import (
+	"bytes"
+	"testing"
+
+	cid "github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+func TestObjAddr(t *testing.T) {
+	hash, err := mh.Sum([]byte("block"), mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v0 := ObjAddr(cid.NewCidV0(hash).Bytes())
+	v1 := ObjAddr(cid.NewCidV1(cid.Raw, hash).Bytes())
+	record := NewRecordObjAddr("/iprs/osr/key/salt")
+
+	for _, a := range []ObjAddr{v0, v1, record} {
+		for _, s := range []string{a.String(), "/ipfs/" + a.String()} {
+			parsed, err := ParseObjAddr(s)
+			if err != nil {
+				t.Errorf("%s: %s", s, err)
+			} else if !bytes.Equal(parsed, a) {
+				t.Errorf("%s: parsed as %s", s, parsed)
+			}
+		}
+	}
+
+	if s := v0.String(); s != hash.B58String() {
+		t.Errorf("CIDv0 written as %s, expected %s", s, hash.B58String())
+	}
+	// Addresses that are not CIDs are still printable
+	if s := ObjAddr("hash").String(); s[0] != 'z' {
+		t.Errorf("invalid address written as %s", s)
+	}
+	for _, s := range []string{"", "/ipfs/", "not-a-cid", "/ipns/" + v0.String()} {
+		if _, err := ParseObjAddr(s); err == nil {
+			t.Errorf("%q: parsed", s)
+		}
+	}
+
+	if key, err := RecordKey(record); err != nil || key != "/iprs/osr/key/salt" {
+		t.Errorf("record key %q (%v)", key, err)
+	}
+	if _, err := RecordKey(v1); err != ErrNotRecordAddr {
+		t.Errorf("block address: got %v, expected ErrNotRecordAddr", err)
+	}
+}
+
+func TestPeerInfo(t *testing.T) {
+	hash, err := mh.Sum([]byte("peer"), mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := hash.B58String()
+
+	cases := []struct {
+		s     string
+		addrs []string
+		err   bool
+	}{
+		{"/p2p/" + id, nil, false},
+		{"/ipfs/" + id, nil, false},
+		{"/ip4/127.0.0.1/tcp/4001/p2p/" + id, []string{"/ip4/127.0.0.1/tcp/4001/p2p/" + id}, false},
+		{"/ip6/::1/tcp/4001/ipfs/" + id, []string{"/ip6/::1/tcp/4001/p2p/" + id}, false},
+		{id, nil, true},
+		{"/p2p/", nil, true},
+		{"/p2p/" + id + "/tcp/4001", nil, true},
+		{"/p2p/not-base58", nil, true},
+		{"/nothing/p2p/" + id, nil, true},
+	}
+
+	for _, c := range cases {
+		p, err := ParsePeerInfo(c.s)
+		if (err != nil) != c.err {
+			t.Errorf("%s: got error %v", c.s, err)
+			continue
+		} else if err != nil {
+			continue
+		}
+
+		if !bytes.Equal(p.Id, hash) || p.String() != "/p2p/"+id {
+			t.Errorf("%s: parsed peer %s", c.s, p)
+		}
+		addrs := p.Multiaddrs()
+		if len(addrs) != len(c.addrs) {
+			t.Errorf("%s: addresses %q, expected %q", c.s, addrs, c.addrs)
+			continue
+		}
+		for i, a := range addrs {
+			if a != c.addrs[i] {
+				t.Errorf("%s: address %s, expected %s", c.s, a, c.addrs[i])
+			}
+		}
+	}
+
+	addr, err := ParsePeerAddr("/ip4/127.0.0.1/udp/4001")
+	if err != nil {
+		t.Fatal(err)
+	} else if addr.String() != "/ip4/127.0.0.1/udp/4001" {
+		t.Errorf("peer address written as %s", addr)
+	}
+	if _, err := ParsePeerAddr("/ip4/300.0.0.1"); err == nil {
+		t.Errorf("invalid peer address parsed")
+	}
+}
@@ -0,0 +1,361 @@
+package ipobj
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+// DAG objects
+//
+// Objects larger than a block are split in chunks linked from a tree of
+// dag-pb nodes, as unixfs files are. NewDAGReader walks such a tree from its
+// root and streams the content of its leaves in order, fetching each block
+// only when the content before it has been read. Raw blocks are leaves on
+// their own.
+//
+// The dag-pb and unixfs schemas are:
+//
+//	message PBLink {
+//		bytes  Hash  = 1;
+//		string Name  = 2;
+//		uint64 Tsize = 3;
+//	}
+//
+//	message PBNode {
+//		repeated PBLink Links = 2;
+//		bytes Data = 1;
+//	}
+//
+//	message Data {
+//		DataType Type = 1;
+//		bytes  Data = 2;
+//		uint64 filesize = 3;
+//		repeated uint64 blocksizes = 4;
+//	}
+
+var ErrObjectTooLarge error = errors.New("Object too large")
+var ErrNotFile error = errors.New("Object is not a file")
+var ErrInvalidNode error = errors.New("Invalid DAG node")
+var ErrBlockMismatch error = errors.New("Block does not match its address")
+
+// unixfs data types
+const (
+	UnixFSRaw       = 0
+	UnixFSDirectory = 1
+	UnixFSFile      = 2
+	UnixFSMetadata  = 3
+	UnixFSSymlink   = 4
+	UnixFSHAMTShard = 5
+)
+
+type DAGLink struct {
+	Hash ObjAddr
+	Name string
+	// Total size of the linked DAG
+	Size uint64
+}
+
+// dag-pb node
+type DAGNode struct {
+	Links []DAGLink
+	Data  []byte
+}
+
+// unixfs data of a dag-pb node
+type UnixFSData struct {
+	Type       uint64
+	Data       []byte
+	FileSize   uint64
+	BlockSizes []uint64
+}
+
+// Fetch a single block, such as Network.GetObject
+type BlockGetter func(ctx context.Context, obj ObjAddr) (io.Reader, error)
+
+func DecodeDAGNode(block []byte) (*DAGNode, error) {
+	var n DAGNode
+	err := readProtoFields(block, func(num uint64, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			n.Data = b
+		case 2:
+			var l DAGLink
+			err := readProtoFields(b, func(num uint64, v uint64, b []byte) error {
+				switch num {
+				case 1:
+					l.Hash = ObjAddr(b)
+				case 2:
+					l.Name = string(b)
+				case 3:
+					l.Size = v
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			n.Links = append(n.Links, l)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func DecodeUnixFSData(data []byte) (*UnixFSData, error) {
+	var fs UnixFSData
+	var hasType bool
+	err := readProtoFields(data, func(num uint64, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			fs.Type = v
+			hasType = true
+		case 2:
+			fs.Data = b
+		case 3:
+			fs.FileSize = v
+		case 4:
+			fs.BlockSizes = append(fs.BlockSizes, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	} else if !hasType {
+		return nil, ErrInvalidNode
+	}
+	return &fs, nil
+}
+
+// Encode the node with its links first, as go-ipfs does
+func (n *DAGNode) Encode() []byte {
+	var res []byte
+	for _, l := range n.Links {
+		var link []byte
+		link = appendProtoBytes(link, 1, l.Hash)
+		link = appendProtoBytes(link, 2, []byte(l.Name))
+		link = appendProtoVarint(link, 3, l.Size)
+		res = appendProtoBytes(res, 2, link)
+	}
+	return appendProtoBytes(res, 1, n.Data)
+}
+
+func (fs *UnixFSData) Encode() []byte {
+	res := appendProtoVarint(nil, 1, fs.Type)
+	if len(fs.Data) > 0 {
+		res = appendProtoBytes(res, 2, fs.Data)
+	}
+	if fs.Type == UnixFSRaw || fs.Type == UnixFSFile {
+		res = appendProtoVarint(res, 3, fs.FileSize)
+	}
+	for _, size := range fs.BlockSizes {
+		res = appendProtoVarint(res, 4, size)
+	}
+	return res
+}
+
+func appendProtoVarint(buf []byte, num uint64, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	buf = append(buf, tmp[:binary.PutUvarint(tmp[:], num<<3)]...)
+	return append(buf, tmp[:binary.PutUvarint(tmp[:], v)]...)
+}
+
+func appendProtoBytes(buf []byte, num uint64, b []byte) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	buf = append(buf, tmp[:binary.PutUvarint(tmp[:], num<<3|2)]...)
+	buf = append(buf, tmp[:binary.PutUvarint(tmp[:], uint64(len(b)))]...)
+	return append(buf, b...)
+}
+
+// Call fn for each field of a protobuf message with its number and either
+// its varint value or its bytes. Fixed size fields are skipped.
+func readProtoFields(data []byte, fn func(num uint64, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrInvalidNode
+		}
+		data = data[n:]
+
+		var v uint64
+		var b []byte
+		switch key & 7 {
+		case 0:
+			v, n = binary.Uvarint(data)
+			if n <= 0 {
+				return ErrInvalidNode
+			}
+			data = data[n:]
+		case 1, 5:
+			size := 8
+			if key&7 == 5 {
+				size = 4
+			}
+			if len(data) < size {
+				return ErrInvalidNode
+			}
+			data = data[size:]
+			continue
+		case 2:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < l {
+				return ErrInvalidNode
+			}
+			b = data[n : n+int(l)]
+			data = data[n+int(l):]
+		default:
+			return ErrInvalidNode
+		}
+
+		err := fn(key>>3, v, b)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fetch a block with get and check it matches its address
+func GetBlock(ctx context.Context, get BlockGetter, obj ObjAddr) ([]byte, error) {
+	c, err := cid.Cast(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := get(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	block, err := ReaderToBytes(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	sum, err := c.Prefix().Sum(block)
+	if err != nil {
+		return nil, err
+	} else if !sum.Equals(c) {
+		return nil, ErrBlockMismatch
+	}
+	return block, nil
+}
+
+type dagReader struct {
+	ctx context.Context
+	get BlockGetter
+	max int64
+
+	// Bytes returned so far
+	read int64
+	// Data of the current node not read yet
+	buf []byte
+	// Links not visited yet, one list per level below the root
+	links [][]ObjAddr
+	err   error
+}
+
+// Stream the content of the DAG at root, fetching blocks with get. The root
+// block is fetched before returning. Reading fails with ErrObjectTooLarge
+// past maxSize bytes, 0 for no limit, and with the context error once ctx is
+// done.
+func NewDAGReader(ctx context.Context, get BlockGetter, root ObjAddr, maxSize int64) (io.Reader, error) {
+	r := &dagReader{
+		ctx: ctx,
+		get: get,
+		max: maxSize,
+	}
+	size, err := r.visit(root)
+	if err != nil {
+		return nil, err
+	} else if maxSize > 0 && size > uint64(maxSize) {
+		return nil, ErrObjectTooLarge
+	}
+	return r, nil
+}
+
+func (r *dagReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 && r.err == nil {
+		if len(r.links) == 0 {
+			return 0, io.EOF
+		}
+
+		level := r.links[len(r.links)-1]
+		next := level[0]
+		if len(level) == 1 {
+			r.links = r.links[:len(r.links)-1]
+		} else {
+			r.links[len(r.links)-1] = level[1:]
+		}
+
+		_, r.err = r.visit(next)
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.read += int64(n)
+	return n, nil
+}
+
+// Fetch and verify a block, make its data the next to read and queue its
+// links. Returns the size of the content below the block as it declares it.
+func (r *dagReader) visit(obj ObjAddr) (uint64, error) {
+	err := r.ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	block, err := GetBlock(r.ctx, r.get, obj)
+	if err != nil {
+		return 0, err
+	}
+	c, err := cid.Cast(obj)
+	if err != nil {
+		return 0, err
+	}
+
+	var data []byte
+	var links []ObjAddr
+	var size uint64
+	switch c.Type() {
+	case cid.Raw:
+		data = block
+		size = uint64(len(block))
+	case cid.DagProtobuf:
+		node, err := DecodeDAGNode(block)
+		if err != nil {
+			return 0, err
+		}
+		fs, err := DecodeUnixFSData(node.Data)
+		if err != nil {
+			return 0, err
+		} else if fs.Type != UnixFSRaw && fs.Type != UnixFSFile {
+			return 0, ErrNotFile
+		}
+		data = fs.Data
+		size = fs.FileSize
+		for _, l := range node.Links {
+			links = append(links, l.Hash)
+		}
+	default:
+		return 0, ErrNotFile
+	}
+
+	if r.max > 0 && r.read+int64(len(data)) > r.max {
+		return 0, ErrObjectTooLarge
+	}
+
+	r.buf = data
+	if len(links) > 0 {
+		r.links = append(r.links, links)
+	}
+	return size, nil
+}
@@ -0,0 +1,133 @@
+package ipobj
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	cid "github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// In memory blocks
+type testBlocks map[string][]byte
+
+func (b testBlocks) put(t *testing.T, block []byte, codec uint64) ObjAddr {
+	hash, err := mh.Sum(block, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ObjAddr(cid.NewCidV1(codec, hash).Bytes())
+	b[string(addr)] = block
+	return addr
+}
+
+func (b testBlocks) get(ctx context.Context, obj ObjAddr) (io.Reader, error) {
+	block, ok := b[string(obj)]
+	if !ok {
+		return nil, NoObject
+	}
+	return BytesToReader(block), nil
+}
+
+// Store a file node linking to children of the given content sizes
+func (b testBlocks) putFile(t *testing.T, data []byte, children []ObjAddr, sizes []uint64) ObjAddr {
+	fs := UnixFSData{Type: UnixFSFile, Data: data, FileSize: uint64(len(data))}
+	var node DAGNode
+	for i, c := range children {
+		fs.FileSize += sizes[i]
+		fs.BlockSizes = append(fs.BlockSizes, sizes[i])
+		node.Links = append(node.Links, DAGLink{Hash: c, Size: sizes[i]})
+	}
+	node.Data = fs.Encode()
+	return b.put(t, node.Encode(), cid.DagProtobuf)
+}
+
+func TestDAGNodeEncoding(t *testing.T) {
+	node := DAGNode{
+		Links: []DAGLink{{Hash: ObjAddr("hash"), Name: "name", Size: 300}},
+		Data:  (&UnixFSData{Type: UnixFSFile, FileSize: 10, BlockSizes: []uint64{4, 6}}).Encode(),
+	}
+
+	dec, err := DecodeDAGNode(node.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dec.Links) != 1 || string(dec.Links[0].Hash) != "hash" || dec.Links[0].Name != "name" || dec.Links[0].Size != 300 {
+		t.Errorf("links decoded as %+v", dec.Links)
+	}
+
+	fs, err := DecodeUnixFSData(dec.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs.Type != UnixFSFile || fs.FileSize != 10 || len(fs.BlockSizes) != 2 || fs.BlockSizes[1] != 6 {
+		t.Errorf("unixfs data decoded as %+v", fs)
+	}
+
+	if _, err := DecodeDAGNode([]byte{0x12, 0x10}); err != ErrInvalidNode {
+		t.Errorf("truncated node: got %v, expected ErrInvalidNode", err)
+	}
+	if _, err := DecodeUnixFSData(nil); err != ErrInvalidNode {
+		t.Errorf("unixfs data without type: got %v, expected ErrInvalidNode", err)
+	}
+}
+
+func TestDAGReader(t *testing.T) {
+	blocks := testBlocks{}
+	a := blocks.put(t, []byte("aaa"), cid.Raw)
+	b := blocks.put(t, []byte("bb"), cid.Raw)
+	c := blocks.put(t, []byte("c"), cid.Raw)
+	inner := blocks.putFile(t, []byte("-"), []ObjAddr{b, c}, []uint64{2, 1})
+	root := blocks.putFile(t, nil, []ObjAddr{a, inner}, []uint64{3, 4})
+
+	ctx := context.Background()
+	r, err := NewDAGReader(ctx, blocks.get, root, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(data) != "aaa-bbc" {
+		t.Errorf("read %q, expected %q", data, "aaa-bbc")
+	}
+
+	// The root declares a size above the limit
+	if _, err := NewDAGReader(ctx, blocks.get, root, 6); err != ErrObjectTooLarge {
+		t.Errorf("size limit: got %v, expected ErrObjectTooLarge", err)
+	}
+
+	// Blocks must match their address
+	blocks[string(b)] = []byte("xx")
+	r, err = NewDAGReader(ctx, blocks.get, root, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(r); err != ErrBlockMismatch {
+		t.Errorf("altered block: got %v, expected ErrBlockMismatch", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := NewDAGReader(canceled, blocks.get, root, 0); err != context.Canceled {
+		t.Errorf("canceled context: got %v", err)
+	}
+}
+
+func TestDAGReaderUndeclaredSize(t *testing.T) {
+	blocks := testBlocks{}
+	big := blocks.put(t, bytes.Repeat([]byte("x"), 100), cid.Raw)
+	// The root lies about the size of its content
+	root := blocks.putFile(t, nil, []ObjAddr{big}, []uint64{1})
+
+	r, err := NewDAGReader(context.Background(), blocks.get, root, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(r); err != ErrObjectTooLarge {
+		t.Errorf("got %v, expected ErrObjectTooLarge", err)
+	}
+}
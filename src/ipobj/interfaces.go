@@ -59,6 +59,11 @@ type Network interface {
 	// Get an object obj
 	GetObject(ctx context.Context, obj ObjAddr) (io.Reader, error)
 
+	// Get an object split in a DAG of blocks, such as a unixfs file, and
+	// stream its content. Fails with ErrObjectTooLarge past maxSize bytes, 0
+	// for no limit.
+	GetDAG(ctx context.Context, obj ObjAddr, maxSize int64) (io.Reader, error)
+
 	// Get a record, a record generally contains a address to the object it
 	// resolves and a version number to be able to order the records
 	GetRecord(ctx context.Context, record string) <-chan *Record
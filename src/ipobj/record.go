@@ -0,0 +1,157 @@
+package ipobj
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Record types
+//
+// Mutable records are published on the DHT under keys of the form
+// /<namespace>/..., the namespace telling which RecordType can decode and
+// order them. Record types register themselves with RegisterRecordType.
+
+var ErrUnknownRecordType error = errors.New("Unknown record type")
+var ErrNoValidRecord error = errors.New("No valid record")
+
+// Returned by RecordType.Decode for records that are valid but expired
+var ErrExpired error = errors.New("Record expired")
+
+// A decoded and verified record
+type DecodedRecord interface {
+	// DHT key the record is published at
+	Key() (string, error)
+}
+
+type RecordType interface {
+	// DHT namespace, without slashes
+	Namespace() string
+
+	// Compute the DHT key of a record value from the record itself, without
+	// checking its validity window
+	Key(value []byte) (string, error)
+
+	// Decode and verify a record value received for key
+	Decode(key string, value []byte) (DecodedRecord, error)
+
+	// Compare two records of the same key. Returns a positive number if a
+	// is more recent than b, a negative number if it is older and zero if
+	// they are the same.
+	Compare(a, b DecodedRecord) (int, error)
+
+	// Order of a record for log messages, empty if the record type has none
+	Order(rec DecodedRecord) string
+
+	// Check if a record revokes its key for good
+	IsRevoked(rec DecodedRecord) bool
+
+	// Describe a record for humans, one "name: value" line per field
+	Describe(rec DecodedRecord) []string
+}
+
+var recordTypesLock sync.Mutex
+var recordTypes = map[string]RecordType{}
+
+// Register a record type for its namespace, replacing any record type
+// registered for the same namespace
+func RegisterRecordType(t RecordType) {
+	recordTypesLock.Lock()
+	defer recordTypesLock.Unlock()
+	recordTypes[t.Namespace()] = t
+}
+
+// List the registered record types, by namespace
+func RecordTypes() []RecordType {
+	recordTypesLock.Lock()
+	defer recordTypesLock.Unlock()
+	var namespaces []string
+	for ns := range recordTypes {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	var res []RecordType
+	for _, ns := range namespaces {
+		res = append(res, recordTypes[ns])
+	}
+	return res
+}
+
+// Find the record type registered for namespace
+func RecordTypeByNamespace(namespace string) (RecordType, error) {
+	recordTypesLock.Lock()
+	defer recordTypesLock.Unlock()
+	t, ok := recordTypes[namespace]
+	if !ok {
+		return nil, ErrUnknownRecordType
+	}
+	return t, nil
+}
+
+// Find the record type of a DHT key /<namespace>/...
+func RecordTypeFor(key string) (RecordType, error) {
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) < 3 || parts[0] != "" {
+		return nil, ErrUnknownRecordType
+	}
+	return RecordTypeByNamespace(parts[1])
+}
+
+// Find the record type of a record value and the DHT key computed from it.
+// If no record type can compute the key, returns the error of the last
+// record type tried.
+func RecordTypeOf(value []byte) (RecordType, string, error) {
+	var err error = ErrUnknownRecordType
+	for _, t := range RecordTypes() {
+		var key string
+		key, err = t.Key(value)
+		if err == nil {
+			return t, key, nil
+		}
+	}
+	return nil, "", err
+}
+
+// Check value is a valid record for key
+func ValidateRecord(key string, value []byte) error {
+	t, err := RecordTypeFor(key)
+	if err != nil {
+		return err
+	}
+	_, err = t.Decode(key, value)
+	return err
+}
+
+// Pick the most recent valid record for key among values
+func SelectRecord(key string, values [][]byte) (int, error) {
+	t, err := RecordTypeFor(key)
+	if err != nil {
+		return 0, err
+	}
+
+	var best int = -1
+	var bestRec DecodedRecord
+
+	for i, value := range values {
+		rec, err := t.Decode(key, value)
+		if err != nil {
+			continue
+		}
+
+		if bestRec != nil {
+			cmp, err := t.Compare(rec, bestRec)
+			if err != nil || cmp <= 0 {
+				continue
+			}
+		}
+
+		best = i
+		bestRec = rec
+	}
+
+	if best < 0 {
+		return 0, ErrNoValidRecord
+	}
+	return best, nil
+}
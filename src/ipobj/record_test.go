@@ -0,0 +1,148 @@
+package ipobj
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var errTestRecord = errors.New("Invalid test record")
+
+// Test records are "<key> <order>", expired when the order is negative
+type testRecord struct {
+	key   string
+	order int
+}
+
+func (r *testRecord) Key() (string, error) {
+	return r.key, nil
+}
+
+type testRecordType struct{}
+
+func (testRecordType) Namespace() string {
+	return "test"
+}
+
+func (testRecordType) parse(value []byte) (*testRecord, error) {
+	parts := strings.Split(string(value), " ")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "/test/") {
+		return nil, errTestRecord
+	}
+	order, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, errTestRecord
+	}
+	return &testRecord{parts[0], order}, nil
+}
+
+func (t testRecordType) Key(value []byte) (string, error) {
+	rec, err := t.parse(value)
+	if err != nil {
+		return "", err
+	}
+	return rec.key, nil
+}
+
+func (t testRecordType) Decode(key string, value []byte) (DecodedRecord, error) {
+	rec, err := t.parse(value)
+	if err != nil {
+		return nil, err
+	} else if rec.key != key {
+		return nil, errTestRecord
+	} else if rec.order < 0 {
+		return nil, ErrExpired
+	}
+	return rec, nil
+}
+
+func (testRecordType) Compare(a, b DecodedRecord) (int, error) {
+	return a.(*testRecord).order - b.(*testRecord).order, nil
+}
+
+func (testRecordType) Order(rec DecodedRecord) string {
+	return strconv.Itoa(rec.(*testRecord).order)
+}
+
+func (testRecordType) IsRevoked(rec DecodedRecord) bool {
+	return false
+}
+
+func (testRecordType) Describe(rec DecodedRecord) []string {
+	return []string{fmt.Sprintf("order: %d", rec.(*testRecord).order)}
+}
+
+func TestRecordTypeRegistry(t *testing.T) {
+	RegisterRecordType(testRecordType{})
+
+	found := false
+	for _, rt := range RecordTypes() {
+		found = found || rt.Namespace() == "test"
+	}
+	if !found {
+		t.Errorf("test record type not listed")
+	}
+
+	cases := map[string]error{
+		"/test/a":    nil,
+		"/test/a/b":  nil,
+		"/other/a":   ErrUnknownRecordType,
+		"test/a":     ErrUnknownRecordType,
+		"/test":      ErrUnknownRecordType,
+		"":           ErrUnknownRecordType,
+		"//test/a/b": ErrUnknownRecordType,
+	}
+	for key, expected := range cases {
+		rt, err := RecordTypeFor(key)
+		if err != expected {
+			t.Errorf("%q: got %v, expected %v", key, err, expected)
+		} else if err == nil && rt.Namespace() != "test" {
+			t.Errorf("%q: got record type %s", key, rt.Namespace())
+		}
+	}
+
+	rt, key, err := RecordTypeOf([]byte("/test/a 1"))
+	if err != nil {
+		t.Fatal(err)
+	} else if rt.Namespace() != "test" || key != "/test/a" {
+		t.Errorf("got record type %s and key %s", rt.Namespace(), key)
+	}
+	if _, _, err := RecordTypeOf([]byte("garbage")); err == nil {
+		t.Errorf("found a record type for garbage")
+	}
+}
+
+func TestSelectRecord(t *testing.T) {
+	RegisterRecordType(testRecordType{})
+
+	values := [][]byte{
+		[]byte("/test/a 1"),
+		[]byte("/test/b 5"),
+		[]byte("/test/a 3"),
+		[]byte("garbage"),
+		[]byte("/test/a -1"),
+		[]byte("/test/a 2"),
+	}
+	errs := []error{nil, errTestRecord, nil, errTestRecord, ErrExpired, nil}
+	for i, value := range values {
+		if err := ValidateRecord("/test/a", value); err != errs[i] {
+			t.Errorf("%s: got %v, expected %v", value, err, errs[i])
+		}
+	}
+
+	best, err := SelectRecord("/test/a", values)
+	if err != nil {
+		t.Fatal(err)
+	} else if best != 2 {
+		t.Errorf("selected %s", values[best])
+	}
+
+	if _, err := SelectRecord("/test/a", values[3:5]); err != ErrNoValidRecord {
+		t.Errorf("no valid record: got %v, expected ErrNoValidRecord", err)
+	}
+	if _, err := SelectRecord("/other/a", values); err != ErrUnknownRecordType {
+		t.Errorf("unknown namespace: got %v, expected ErrUnknownRecordType", err)
+	}
+}